@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Jayant-Verma/rssagg/internal/database"
+)
+
+// startCleanup periodically removes feeds that nobody follows anymore. Feeds
+// younger than gracePeriod are kept so a creator can still follow them, and
+// feeds holding starred posts are kept so those posts survive.
+// When postRetention is set, posts published before it are pruned as well,
+// except for posts that any user has starred. Expired login sessions are
+// removed along with their refresh tokens, and so are expired password reset
+// and verification tokens.
+func startCleanup(
+	db *database.Queries,
+	timeBetweenRuns time.Duration,
+	gracePeriod time.Duration,
+	postRetention time.Duration,
+) {
+	log.Printf("Cleaning up unfollowed feeds every %s", timeBetweenRuns)
+
+	ticker := time.NewTicker(timeBetweenRuns)
+	for ; ; <-ticker.C {
+		deleted, err := db.DeleteUnfollowedFeeds(
+			context.Background(),
+			time.Now().UTC().Add(-gracePeriod),
+		)
+		if err != nil {
+			log.Printf("Failed to delete unfollowed feeds: %v", err)
+		} else if deleted > 0 {
+			log.Printf("Deleted %v unfollowed feeds", deleted)
+		}
+
+		deleted, err = db.DeleteExpiredSessions(context.Background(), time.Now().UTC())
+		if err != nil {
+			log.Printf("Failed to delete expired sessions: %v", err)
+		} else if deleted > 0 {
+			log.Printf("Deleted %v expired sessions", deleted)
+		}
+
+		deleted, err = db.DeleteExpiredUserTokens(context.Background(), time.Now().UTC())
+		if err != nil {
+			log.Printf("Failed to delete expired email tokens: %v", err)
+		} else if deleted > 0 {
+			log.Printf("Deleted %v expired email tokens", deleted)
+		}
+
+		if postRetention <= 0 {
+			continue
+		}
+		deleted, err = db.DeleteOldPosts(
+			context.Background(),
+			time.Now().UTC().Add(-postRetention),
+		)
+		if err != nil {
+			log.Printf("Failed to delete old posts: %v", err)
+		} else if deleted > 0 {
+			log.Printf("Deleted %v posts older than %s", deleted, postRetention)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	tokenCookieName   = "token"
+	refreshCookieName = "refresh_token"
+	csrfCookieName    = "csrf_token"
+	csrfHeaderName    = "X-CSRF-Token"
+	// refreshCookiePath keeps the refresh token from being sent anywhere but
+	// the auth endpoints.
+	refreshCookiePath = "/v1/auth"
+)
+
+// cookieConfig controls the cookies set at login. Secure should be on
+// whenever the API is served over HTTPS.
+type cookieConfig struct {
+	Secure bool
+}
+
+// csrfToken is the CSRF token of a login session. Browsers using cookie auth
+// must echo it in the X-CSRF-Token header of state-changing requests; it is
+// derived from the session, so nothing else needs to be stored.
+func csrfToken(sessionID uuid.UUID) string {
+	mac := hmac.New(sha256.New, jwtSecret)
+	mac.Write([]byte("csrf:" + sessionID.String()))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// validCSRFToken reports whether r carries the CSRF token of the session.
+func validCSRFToken(r *http.Request, sessionID uuid.UUID) bool {
+	token := r.Header.Get(csrfHeaderName)
+	return token != "" && hmac.Equal([]byte(token), []byte(csrfToken(sessionID)))
+}
+
+// isSafeMethod reports whether a request method can't change state, so it
+// needs no CSRF token.
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+// setSessionCookies stores a login's tokens in cookies. The CSRF cookie is
+// readable by scripts so the frontend can copy it into the header. All of
+// them last as long as the refresh token, so the browser can still refresh
+// after the access token expires and drops nothing the session needs.
+func (apiCfg *apiConfig) setSessionCookies(w http.ResponseWriter, tokens tokenPair) {
+	maxAge := int(time.Until(tokens.RefreshTokenExpiresAt).Seconds())
+	http.SetCookie(w, &http.Cookie{
+		Name:     tokenCookieName,
+		Value:    tokens.Token,
+		Path:     "/",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   apiCfg.Cookies.Secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.SetCookie(w, &http.Cookie{
+		Name:     refreshCookieName,
+		Value:    tokens.RefreshToken,
+		Path:     refreshCookiePath,
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   apiCfg.Cookies.Secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.SetCookie(w, &http.Cookie{
+		Name:     csrfCookieName,
+		Value:    tokens.CSRFToken,
+		Path:     "/",
+		MaxAge:   maxAge,
+		Secure:   apiCfg.Cookies.Secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func (apiCfg *apiConfig) clearSessionCookies(w http.ResponseWriter) {
+	for _, cookie := range []struct{ name, path string }{
+		{tokenCookieName, "/"},
+		{refreshCookieName, refreshCookiePath},
+		{csrfCookieName, "/"},
+	} {
+		http.SetCookie(w, &http.Cookie{
+			Name:     cookie.name,
+			Value:    "",
+			Path:     cookie.path,
+			MaxAge:   -1,
+			HttpOnly: cookie.name != csrfCookieName,
+			Secure:   apiCfg.Cookies.Secure,
+			SameSite: http.SameSiteLaxMode,
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"html"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+type FeedCandidate struct {
+	URL   string `json:"url"`
+	Title string `json:"title,omitempty"`
+	Type  string `json:"type,omitempty"`
+}
+
+// feedLinkTypes are the <link type="..."> values that point at a feed.
+var feedLinkTypes = map[string]bool{
+	"application/rss+xml":   true,
+	"application/atom+xml":  true,
+	"application/feed+json": true,
+}
+
+// commonFeedPaths are probed when a page doesn't advertise its feed.
+var commonFeedPaths = []string{
+	"/feed",
+	"/rss.xml",
+	"/feed.xml",
+	"/atom.xml",
+	"/rss",
+	"/index.xml",
+}
+
+var (
+	linkTagRe = regexp.MustCompile(`(?is)<link\b[^>]*>`)
+	attrRe    = regexp.MustCompile(`(?is)([a-z][a-z0-9_:-]*)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+))`)
+)
+
+// discoveryTimeout bounds probing the common feed paths as a whole.
+const discoveryTimeout = 15 * time.Second
+
+// discoverFeeds looks for the feeds offered by an HTML page, first through
+// its <link rel="alternate"> tags and then by probing common feed paths.
+func discoverFeeds(ctx context.Context, base *url.URL, page []byte) []FeedCandidate {
+	candidates := feedLinksFromHTML(base, string(page))
+	if len(candidates) > 0 {
+		return candidates
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, discoveryTimeout)
+	defer cancel()
+
+	// The probes run at the same time; found keeps them in path order.
+	found := make([]bool, len(commonFeedPaths))
+	wg := sync.WaitGroup{}
+	for i, path := range commonFeedPaths {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			probeURL := base.ResolveReference(&url.URL{Path: path}).String()
+			found[i] = probeFeed(ctx, probeURL)
+		}()
+	}
+	wg.Wait()
+
+	for i, path := range commonFeedPaths {
+		if found[i] {
+			probeURL := base.ResolveReference(&url.URL{Path: path}).String()
+			candidates = append(candidates, FeedCandidate{URL: probeURL})
+		}
+	}
+	return candidates
+}
+
+func isHTML(resp *http.Response, dat []byte) bool {
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(dat)
+	}
+	return strings.Contains(strings.ToLower(contentType), "html")
+}
+
+// feedLinksFromHTML collects <link rel="alternate"> tags that point at a feed.
+func feedLinksFromHTML(base *url.URL, page string) []FeedCandidate {
+	candidates := []FeedCandidate{}
+	seen := map[string]bool{}
+
+	for _, tag := range linkTagRe.FindAllString(page, -1) {
+		attrs := map[string]string{}
+		for _, m := range attrRe.FindAllStringSubmatch(tag, -1) {
+			attrs[strings.ToLower(m[1])] = html.UnescapeString(m[2] + m[3] + m[4])
+		}
+
+		if !hasToken(attrs["rel"], "alternate") {
+			continue
+		}
+		linkType := strings.ToLower(strings.TrimSpace(attrs["type"]))
+		if !feedLinkTypes[linkType] {
+			continue
+		}
+
+		href, err := base.Parse(strings.TrimSpace(attrs["href"]))
+		if err != nil || attrs["href"] == "" {
+			continue
+		}
+		feedURL := href.String()
+		if seen[feedURL] {
+			continue
+		}
+		seen[feedURL] = true
+
+		candidates = append(candidates, FeedCandidate{
+			URL:   feedURL,
+			Title: strings.TrimSpace(attrs["title"]),
+			Type:  linkType,
+		})
+	}
+	return candidates
+}
+
+func hasToken(list, token string) bool {
+	for _, t := range strings.Fields(strings.ToLower(list)) {
+		if t == token {
+			return true
+		}
+	}
+	return false
+}
+
+// probeFeed reports whether feedURL serves something that parses as a feed.
+func probeFeed(ctx context.Context, feedURL string) bool {
+	dat, resp, err := fetchURL(ctx, feedURL)
+	if err != nil || isHTML(resp, dat) {
+		return false
+	}
+	_, _, err = parseFeed(dat)
+	return err == nil
+}
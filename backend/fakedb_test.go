@@ -0,0 +1,290 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Jayant-Verma/rssagg/internal/database"
+	"github.com/google/uuid"
+)
+
+// fakeStore is an in-memory stand-in for the tables the session code uses.
+// It answers the generated queries by name, so tests run without Postgres.
+type fakeStore struct {
+	mu            sync.Mutex
+	users         map[uuid.UUID]database.User
+	sessions      map[uuid.UUID]database.Session
+	refreshTokens map[uuid.UUID]database.RefreshToken
+
+	// beforeUseRefreshToken runs just before a refresh token is marked used,
+	// standing in for a request that exchanges the same token concurrently.
+	beforeUseRefreshToken func()
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{
+		users:         map[uuid.UUID]database.User{},
+		sessions:      map[uuid.UUID]database.Session{},
+		refreshTokens: map[uuid.UUID]database.RefreshToken{},
+	}
+}
+
+var queryNameRe = regexp.MustCompile(`^-- name: (\w+)`)
+
+func (s *fakeStore) query(query string, args []driver.NamedValue) (*fakeRows, error) {
+	name := queryNameRe.FindStringSubmatch(query)
+	if name == nil {
+		return nil, fmt.Errorf("fakedb: query without a name: %q", query)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch name[1] {
+	case "GetUserByID":
+		user, ok := s.users[argUUID(args, 0)]
+		if !ok {
+			return &fakeRows{}, nil
+		}
+		return rowsOf(userRow(user)), nil
+	case "GetUserByAPIKeyHash":
+		for _, user := range s.users {
+			if user.ApiKeyHash.Valid && user.ApiKeyHash.String == args[0].Value.(string) {
+				return rowsOf(userRow(user)), nil
+			}
+		}
+		return &fakeRows{}, nil
+	case "GetSessionByID":
+		session, ok := s.sessions[argUUID(args, 0)]
+		if !ok {
+			return &fakeRows{}, nil
+		}
+		return rowsOf(sessionRow(session)), nil
+	case "GetRefreshTokenByHash":
+		for _, token := range s.refreshTokens {
+			if token.TokenHash == args[0].Value.(string) {
+				return rowsOf(refreshTokenRow(token)), nil
+			}
+		}
+		return &fakeRows{}, nil
+	case "CreateRefreshToken":
+		token := database.RefreshToken{
+			ID:        argUUID(args, 0),
+			CreatedAt: args[1].Value.(time.Time),
+			SessionID: argUUID(args, 2),
+			TokenHash: args[3].Value.(string),
+			ExpiresAt: args[4].Value.(time.Time),
+		}
+		s.refreshTokens[token.ID] = token
+		return rowsOf(refreshTokenRow(token)), nil
+	}
+	return nil, fmt.Errorf("fakedb: unexpected query %s", name[1])
+}
+
+func (s *fakeStore) exec(query string, args []driver.NamedValue) (int64, error) {
+	name := queryNameRe.FindStringSubmatch(query)
+	if name == nil {
+		return 0, fmt.Errorf("fakedb: query without a name: %q", query)
+	}
+
+	if name[1] == "UseRefreshToken" && s.beforeUseRefreshToken != nil {
+		s.beforeUseRefreshToken()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch name[1] {
+	case "UseRefreshToken":
+		token, ok := s.refreshTokens[argUUID(args, 0)]
+		if !ok || token.UsedAt.Valid {
+			return 0, nil
+		}
+		token.UsedAt = sql.NullTime{Time: time.Now().UTC(), Valid: true}
+		s.refreshTokens[token.ID] = token
+		return 1, nil
+	case "RevokeSession":
+		session, ok := s.sessions[argUUID(args, 0)]
+		if !ok || session.RevokedAt.Valid {
+			return 0, nil
+		}
+		session.RevokedAt = sql.NullTime{Time: time.Now().UTC(), Valid: true}
+		s.sessions[session.ID] = session
+		return 1, nil
+	case "ExtendSession":
+		session, ok := s.sessions[argUUID(args, 0)]
+		if !ok {
+			return 0, nil
+		}
+		session.ExpiresAt = args[1].Value.(time.Time)
+		s.sessions[session.ID] = session
+		return 1, nil
+	case "TouchSession":
+		session, ok := s.sessions[argUUID(args, 0)]
+		if !ok {
+			return 0, nil
+		}
+		session.LastSeenAt = time.Now().UTC()
+		session.Ip = args[1].Value.(string)
+		s.sessions[session.ID] = session
+		return 1, nil
+	}
+	return 0, fmt.Errorf("fakedb: unexpected query %s", name[1])
+}
+
+func argUUID(args []driver.NamedValue, i int) uuid.UUID {
+	return uuid.MustParse(args[i].Value.(string))
+}
+
+func driverNullTime(t sql.NullTime) driver.Value {
+	if !t.Valid {
+		return nil
+	}
+	return t.Time
+}
+
+func driverNullString(s sql.NullString) driver.Value {
+	if !s.Valid {
+		return nil
+	}
+	return s.String
+}
+
+func userRow(u database.User) []driver.Value {
+	return []driver.Value{
+		u.ID.String(), u.CreatedAt, u.UpdatedAt, u.Name, driverNullString(u.ApiKeyHash),
+		u.Email, u.PasswordHash, u.IsAdmin, driverNullTime(u.EmailVerifiedAt), driverNullString(u.FeedTokenHash),
+	}
+}
+
+func sessionRow(s database.Session) []driver.Value {
+	return []driver.Value{
+		s.ID.String(), s.CreatedAt, s.UserID.String(), s.ExpiresAt,
+		driverNullTime(s.RevokedAt), s.UserAgent, s.Ip, s.LastSeenAt,
+	}
+}
+
+func refreshTokenRow(t database.RefreshToken) []driver.Value {
+	return []driver.Value{
+		t.ID.String(), t.CreatedAt, t.SessionID.String(), t.TokenHash, t.ExpiresAt, driverNullTime(t.UsedAt),
+	}
+}
+
+type fakeRows struct {
+	rows [][]driver.Value
+	next int
+}
+
+func rowsOf(rows ...[]driver.Value) *fakeRows {
+	return &fakeRows{rows: rows}
+}
+
+func (r *fakeRows) Columns() []string {
+	if len(r.rows) == 0 {
+		return nil
+	}
+	return make([]string, len(r.rows[0]))
+}
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.rows) {
+		return io.EOF
+	}
+	copy(dest, r.rows[r.next])
+	r.next++
+	return nil
+}
+
+type fakeConn struct{ store *fakeStore }
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("fakedb: prepared statements are not supported")
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+// Begin hands out transactions that apply writes immediately; the tests
+// don't depend on rollbacks.
+func (c *fakeConn) Begin() (driver.Tx, error) { return fakeTx{}, nil }
+
+func (c *fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return c.store.query(query, args)
+}
+
+func (c *fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	n, err := c.store.exec(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(n), nil
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeConnector struct{ store *fakeStore }
+
+func (c fakeConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return &fakeConn{store: c.store}, nil
+}
+
+func (c fakeConnector) Driver() driver.Driver { return fakeDriver{} }
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	return nil, fmt.Errorf("fakedb: open through a connector")
+}
+
+// newTestAPIConfig returns an apiConfig backed by store, with a known JWT
+// secret.
+func newTestAPIConfig(t *testing.T, store *fakeStore) *apiConfig {
+	t.Helper()
+	t.Setenv("JWT_SECRET", "test-secret")
+	jwtSecret = []byte("test-secret")
+
+	conn := sql.OpenDB(fakeConnector{store: store})
+	t.Cleanup(func() { conn.Close() })
+
+	return &apiConfig{
+		DB:              database.New(conn),
+		Conn:            conn,
+		AccessTokenTTL:  15 * time.Minute,
+		RefreshTokenTTL: 24 * time.Hour,
+	}
+}
+
+// addTestSession stores a user with one login session and returns both.
+func addTestSession(store *fakeStore) (database.User, database.Session) {
+	now := time.Now().UTC()
+	user := database.User{
+		ID:        uuid.New(),
+		CreatedAt: now,
+		UpdatedAt: now,
+		Name:      "Test User",
+		Email:     "test@example.com",
+	}
+	session := database.Session{
+		ID:         uuid.New(),
+		CreatedAt:  now,
+		UserID:     user.ID,
+		ExpiresAt:  now.Add(24 * time.Hour),
+		UserAgent:  "test",
+		Ip:         "192.0.2.1",
+		LastSeenAt: now,
+	}
+	store.users[user.ID] = user
+	store.sessions[session.ID] = session
+	return user, session
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// previewItemLimit is how many of the latest items a preview returns.
+const previewItemLimit = 10
+
+type FeedPreview struct {
+	URL         string            `json:"url"`
+	Format      string            `json:"format"`
+	Title       string            `json:"title"`
+	Description string            `json:"description"`
+	Link        string            `json:"link"`
+	Language    string            `json:"language"`
+	ItemCount   int               `json:"item_count"`
+	Items       []FeedPreviewItem `json:"items"`
+	Warnings    []string          `json:"warnings"`
+}
+
+type FeedPreviewItem struct {
+	Title       string     `json:"title"`
+	URL         string     `json:"url"`
+	Description string     `json:"description"`
+	PublishedAt *time.Time `json:"published_at"`
+}
+
+// buildFeedPreview summarizes a parsed feed and collects the problems the
+// scraper would run into, without touching the database.
+func buildFeedPreview(feedURL, format string, rssFeed RSSFeed) FeedPreview {
+	preview := FeedPreview{
+		URL:         feedURL,
+		Format:      format,
+		Title:       strings.TrimSpace(rssFeed.Channel.Title),
+		Description: strings.TrimSpace(rssFeed.Channel.Description),
+		Link:        strings.TrimSpace(rssFeed.Channel.Link),
+		Language:    strings.TrimSpace(rssFeed.Channel.Language),
+		ItemCount:   len(rssFeed.Channel.Item),
+		Items:       []FeedPreviewItem{},
+		Warnings:    []string{},
+	}
+
+	if preview.Title == "" {
+		preview.Warnings = append(preview.Warnings, "Feed has no title")
+	}
+	if preview.ItemCount == 0 {
+		preview.Warnings = append(preview.Warnings, "Feed has no items")
+	}
+
+	for i, item := range rssFeed.Channel.Item {
+		previewItem := FeedPreviewItem{
+			Title:       strings.TrimSpace(item.Title),
+			URL:         strings.TrimSpace(item.Link),
+			Description: item.Description,
+		}
+
+		if previewItem.Title == "" {
+			preview.Warnings = append(preview.Warnings, fmt.Sprintf("Item %d has no title", i+1))
+		}
+		if previewItem.URL == "" {
+			preview.Warnings = append(preview.Warnings, fmt.Sprintf("Item %d has no link", i+1))
+		}
+		pubAt, err := parseTime(item.PubDate)
+		if err != nil {
+			preview.Warnings = append(preview.Warnings, fmt.Sprintf("Item %d has an unparseable date %q and will be skipped", i+1, item.PubDate))
+		} else {
+			pubAt = pubAt.UTC()
+			previewItem.PublishedAt = &pubAt
+		}
+
+		preview.Items = append(preview.Items, previewItem)
+	}
+
+	// Newest first, undated items last.
+	sort.SliceStable(preview.Items, func(i, j int) bool {
+		a, b := preview.Items[i].PublishedAt, preview.Items[j].PublishedAt
+		if a == nil || b == nil {
+			return b == nil && a != nil
+		}
+		return a.After(*b)
+	})
+	if len(preview.Items) > previewItemLimit {
+		preview.Items = preview.Items[:previewItemLimit]
+	}
+
+	return preview
+}
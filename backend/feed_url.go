@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// normalizeFeedURL turns user input into the canonical form feeds are stored
+// under, so the same feed submitted twice maps to a single row.
+func normalizeFeedURL(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", errors.New("URL is required")
+	}
+
+	// feed:// and feed:https:// are old browser conventions for "subscribe".
+	if strings.HasPrefix(raw, "feed:") {
+		raw = strings.TrimPrefix(strings.TrimPrefix(raw, "feed:"), "//")
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", errors.New("URL is not valid")
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", errors.New("URL must use http or https")
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "" {
+		return "", errors.New("URL has no host")
+	}
+	port := u.Port()
+	if (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		port = ""
+	}
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	u.Host = host
+	if port != "" {
+		u.Host = host + ":" + port
+	}
+
+	if u.Path == "" {
+		u.Path = "/"
+	}
+	u.Fragment = ""
+	u.RawFragment = ""
+
+	// Tracking parameters never change the feed that is served.
+	query := u.Query()
+	for key := range query {
+		if strings.HasPrefix(strings.ToLower(key), "utm_") {
+			query.Del(key)
+		}
+	}
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
+
+// resolveSiteURL resolves the site link a feed advertises against the feed's
+// own URL, returning "" when it is not an http or https URL.
+func resolveSiteURL(feedURL, link string) string {
+	link = strings.TrimSpace(link)
+	if link == "" {
+		return ""
+	}
+
+	base, err := url.Parse(feedURL)
+	if err != nil {
+		return ""
+	}
+	u, err := base.Parse(link)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ""
+	}
+	return u.String()
+}
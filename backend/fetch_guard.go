@@ -0,0 +1,104 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// fetchTimeout bounds a single remote fetch, redirects included.
+const fetchTimeout = 10 * time.Second
+
+// maxFetchRedirects matches the limit net/http applies by default.
+const maxFetchRedirects = 10
+
+var errBlockedAddress = errors.New("URL resolves to a private or reserved address")
+
+// blockedNetworks are reserved ranges the net.IP helpers don't cover.
+var blockedNetworks = mustParseCIDRs(
+	"0.0.0.0/8",     // "this network"
+	"100.64.0.0/10", // carrier-grade NAT
+	"192.0.0.0/24",  // IETF protocol assignments
+	"198.18.0.0/15", // benchmarking
+	"240.0.0.0/4",   // reserved, including broadcast
+	"64:ff9b::/96",  // NAT64, which maps onto IPv4 addresses
+)
+
+// feedHTTPClient fetches user-supplied URLs. The dialer checks the address
+// each connection goes to after DNS resolution, so neither a hostname that
+// resolves to an internal address nor a redirect to one reaches the
+// server's own network.
+var feedHTTPClient = &http.Client{
+	Timeout: fetchTimeout,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: fetchTimeout,
+			Control: checkDialAddress,
+		}).DialContext,
+		TLSHandshakeTimeout:   fetchTimeout,
+		ResponseHeaderTimeout: fetchTimeout,
+		MaxIdleConnsPerHost:   2,
+		IdleConnTimeout:       90 * time.Second,
+	},
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if len(via) >= maxFetchRedirects {
+			return fmt.Errorf("stopped after %d redirects", maxFetchRedirects)
+		}
+		return checkFetchURL(req.URL)
+	},
+}
+
+// checkFetchURL rejects URLs the fetcher must not follow.
+func checkFetchURL(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return errors.New("URL must use http or https")
+	}
+	if u.Hostname() == "" {
+		return errors.New("URL has no host")
+	}
+	return nil
+}
+
+// checkDialAddress runs before every outgoing connection with the resolved
+// address.
+func checkDialAddress(network, address string, c syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || isBlockedIP(ip) {
+		return errBlockedAddress
+	}
+	return nil
+}
+
+func isBlockedIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return true
+	}
+	for _, network := range blockedNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIsBlockedIP(t *testing.T) {
+	tests := []struct {
+		ip      string
+		blocked bool
+	}{
+		{"127.0.0.1", true},
+		{"10.1.2.3", true},
+		{"172.16.0.1", true},
+		{"192.168.1.1", true},
+		{"169.254.169.254", true},
+		{"100.64.0.1", true},
+		{"0.0.0.0", true},
+		{"224.0.0.1", true},
+		{"::1", true},
+		{"fd00::1", true},
+		{"fe80::1", true},
+		{"::ffff:127.0.0.1", true},
+		{"93.184.216.34", false},
+		{"2606:2800:220:1:248:1893:25c8:1946", false},
+	}
+
+	for _, tt := range tests {
+		if got := isBlockedIP(net.ParseIP(tt.ip)); got != tt.blocked {
+			t.Errorf("isBlockedIP(%s) = %v, want %v", tt.ip, got, tt.blocked)
+		}
+	}
+}
+
+func TestFetchURLRejectsLoopback(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<rss></rss>"))
+	}))
+	defer srv.Close()
+
+	_, _, err := fetchURL(context.Background(), srv.URL)
+	if !errors.Is(err, errBlockedAddress) {
+		t.Fatalf("fetchURL(%s) error = %v, want %v", srv.URL, err, errBlockedAddress)
+	}
+}
+
+func TestFetchURLRejectsOtherSchemes(t *testing.T) {
+	for _, rawURL := range []string{"file:///etc/passwd", "gopher://example.com/", "http:///feed"} {
+		_, _, err := fetchURL(context.Background(), rawURL)
+		if err == nil {
+			t.Errorf("fetchURL(%s) succeeded, want an error", rawURL)
+		}
+	}
+}
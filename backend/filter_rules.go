@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"html"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Jayant-Verma/rssagg/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	filterActionHide     = "hide"
+	filterActionMarkRead = "mark_read"
+	filterActionStar     = "star"
+	filterActionTag      = "tag"
+
+	filterMatchKeyword = "keyword"
+	filterMatchRegex   = "regex"
+)
+
+var filterActions = map[string]bool{
+	filterActionHide:     true,
+	filterActionMarkRead: true,
+	filterActionStar:     true,
+	filterActionTag:      true,
+}
+
+// filterMatchFields are the parts of a post a rule can look at. "text" is
+// the title and the content together.
+var filterMatchFields = map[string]bool{
+	"title":    true,
+	"content":  true,
+	"text":     true,
+	"author":   true,
+	"category": true,
+}
+
+var htmlTagRe = regexp.MustCompile(`<[^>]*>`)
+
+// filterRule is a rule ready to be matched against posts. Keywords match
+// anywhere in the field, case-insensitively, including within a single
+// category; regular expressions use RE2 syntax as written.
+type filterRule struct {
+	database.FilterRule
+	re      *regexp.Regexp
+	keyword string
+}
+
+func compileFilterRule(rule database.FilterRule) (filterRule, error) {
+	compiled := filterRule{FilterRule: rule}
+	if rule.MatchType == filterMatchRegex {
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return filterRule{}, err
+		}
+		compiled.re = re
+	} else {
+		compiled.keyword = strings.ToLower(rule.Pattern)
+	}
+	return compiled, nil
+}
+
+func (rule filterRule) matches(post database.Post) bool {
+	switch rule.MatchField {
+	case "title":
+		return rule.matchText(post.Title)
+	case "content":
+		return rule.matchContent(post)
+	case "text":
+		return rule.matchText(post.Title) || rule.matchContent(post)
+	case "author":
+		return post.Author.Valid && rule.matchText(post.Author.String)
+	case "category":
+		if !post.Categories.Valid {
+			return false
+		}
+		for _, category := range strings.Split(post.Categories.String, "\n") {
+			if rule.matchText(category) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (rule filterRule) matchContent(post database.Post) bool {
+	return rule.matchText(htmlText(post.Description.String)) || rule.matchText(htmlText(post.Content.String))
+}
+
+func (rule filterRule) matchText(s string) bool {
+	if rule.re != nil {
+		return rule.re.MatchString(s)
+	}
+	return strings.Contains(strings.ToLower(s), rule.keyword)
+}
+
+// htmlText reduces HTML to its text with runs of whitespace collapsed, so
+// keywords spanning markup still match.
+func htmlText(s string) string {
+	text := html.UnescapeString(htmlTagRe.ReplaceAllString(s, " "))
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// applyFilterRule records that rule matched a post and runs its action in
+// one transaction. The action only runs the first time a rule matches a
+// post, so applying a rule again doesn't undo what the user changed by hand
+// since. It reports whether the match is new.
+func applyFilterRule(ctx context.Context, conn *sql.DB, db *database.Queries, rule filterRule, postID uuid.UUID) (bool, error) {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+	qtx := db.WithTx(tx)
+
+	recorded, err := qtx.RecordFilterRuleMatch(ctx, database.RecordFilterRuleMatchParams{
+		FilterRuleID: rule.ID,
+		PostID:       postID,
+	})
+	if err != nil {
+		return false, err
+	}
+	if recorded == 0 {
+		return false, nil
+	}
+
+	switch rule.Action {
+	case filterActionHide:
+		_, err = qtx.HidePost(ctx, database.HidePostParams{
+			ID:     postID,
+			UserID: rule.UserID,
+		})
+	case filterActionMarkRead:
+		_, err = qtx.SetPostRead(ctx, database.SetPostReadParams{
+			Read:   true,
+			PostID: postID,
+			UserID: rule.UserID,
+		})
+	case filterActionStar:
+		_, err = qtx.StarPost(ctx, database.StarPostParams{
+			ID:     postID,
+			UserID: rule.UserID,
+		})
+	case filterActionTag:
+		var tag database.Tag
+		tag, err = qtx.UpsertTag(ctx, database.UpsertTagParams{
+			ID:        uuid.New(),
+			CreatedAt: time.Now().UTC(),
+			UserID:    rule.UserID,
+			Name:      rule.Tag.String,
+		})
+		if err == nil {
+			err = qtx.TagPost(ctx, database.TagPostParams{
+				TagID:  tag.ID,
+				PostID: postID,
+			})
+		}
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+func postCategories(categories []string) sql.NullString {
+	cleaned := []string{}
+	for _, category := range categories {
+		category = strings.TrimSpace(strings.ReplaceAll(category, "\n", " "))
+		if category != "" {
+			cleaned = append(cleaned, category)
+		}
+	}
+	if len(cleaned) == 0 {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: strings.Join(cleaned, "\n"), Valid: true}
+}
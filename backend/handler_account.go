@@ -0,0 +1,241 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Jayant-Verma/rssagg/internal/auth"
+	"github.com/Jayant-Verma/rssagg/internal/database"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Reset and verification emails are throttled so the endpoints can't be
+// used to flood an inbox or to probe many addresses from one client.
+var (
+	forgotPasswordByEmail     = newRateLimiter(3, time.Hour)
+	forgotPasswordByIP        = newRateLimiter(10, time.Hour)
+	resendVerificationByEmail = newRateLimiter(3, time.Hour)
+	resendVerificationByIP    = newRateLimiter(10, time.Hour)
+)
+
+// handlerForgotPassword mails a password reset link. It answers the same
+// whether or not the address has an account.
+func (apiCfg *apiConfig) handlerForgotPassword(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Email string `json:"email"`
+	}
+	var params parameters
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if params.Email == "" {
+		respondWithError(w, http.StatusBadRequest, "Email is required")
+		return
+	}
+	// Limits apply whether or not the address has an account, so they
+	// don't reveal which ones do.
+	if !forgotPasswordByIP.allow(apiCfg.clientIP(r)) || !forgotPasswordByEmail.allow(strings.ToLower(params.Email)) {
+		respondWithError(w, http.StatusTooManyRequests, "Too many password reset requests, try again later")
+		return
+	}
+
+	user, err := apiCfg.DB.GetUserByEmail(r.Context(), params.Email)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Couldn't get user: %v", err))
+		return
+	}
+	if err == nil {
+		sendInBackground("password reset", user, apiCfg.sendPasswordReset)
+	}
+
+	respondWithJSON(w, http.StatusAccepted, map[string]string{
+		"message": "If an account uses this email, a reset link is on its way",
+	})
+}
+
+// handlerResetPassword sets a new password with the token from a reset
+// email, logging the user out everywhere and revoking their API key and
+// personal access tokens.
+func (apiCfg *apiConfig) handlerResetPassword(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+	var params parameters
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if params.Token == "" || params.Password == "" {
+		respondWithError(w, http.StatusBadRequest, "Token and password are required")
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(params.Password), bcrypt.DefaultCost)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to hash password")
+		return
+	}
+
+	tx, err := apiCfg.Conn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to start transaction: %v", err))
+		return
+	}
+	defer tx.Rollback()
+	qtx := apiCfg.DB.WithTx(tx)
+
+	token, ok := useUserToken(w, r, qtx, params.Token, userTokenPasswordReset)
+	if !ok {
+		return
+	}
+
+	_, err = qtx.SetUserPasswordHash(r.Context(), database.SetUserPasswordHashParams{
+		ID:           token.UserID,
+		PasswordHash: string(hashedPassword),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to set password: %v", err))
+		return
+	}
+
+	// Whoever knew the old password may still be logged in.
+	_, err = qtx.RevokeSessionsForUser(r.Context(), database.RevokeSessionsForUserParams{
+		UserID: token.UserID,
+		ID:     uuid.Nil,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to revoke sessions: %v", err))
+		return
+	}
+
+	// Or have made an API key or access tokens while they were.
+	_, err = qtx.SetUserAPIKeyHash(r.Context(), database.SetUserAPIKeyHashParams{
+		ID: token.UserID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to revoke API key: %v", err))
+		return
+	}
+	_, err = qtx.RevokePersonalAccessTokensForUser(r.Context(), token.UserID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to revoke access tokens: %v", err))
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to commit transaction: %v", err))
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{
+		"message": "Password reset successfully, please log in again",
+	})
+}
+
+// handlerVerifyEmail confirms the user's email address with the token from
+// a verification email.
+func (apiCfg *apiConfig) handlerVerifyEmail(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Token string `json:"token"`
+	}
+	var params parameters
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if params.Token == "" {
+		respondWithError(w, http.StatusBadRequest, "Token is required")
+		return
+	}
+
+	tx, err := apiCfg.Conn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to start transaction: %v", err))
+		return
+	}
+	defer tx.Rollback()
+	qtx := apiCfg.DB.WithTx(tx)
+
+	token, ok := useUserToken(w, r, qtx, params.Token, userTokenEmailVerification)
+	if !ok {
+		return
+	}
+
+	user, err := qtx.SetUserEmailVerified(r.Context(), token.UserID)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Already verified; the token is still spent.
+		user, err = qtx.GetUserByID(r.Context(), token.UserID)
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to verify email: %v", err))
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to commit transaction: %v", err))
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, databaseUserToUser(user))
+}
+
+// handlerResendVerification mails the user a new verification link.
+func (apiCfg *apiConfig) handlerResendVerification(w http.ResponseWriter, r *http.Request, user database.User) {
+	if user.EmailVerifiedAt.Valid {
+		respondWithError(w, http.StatusConflict, "Email address is already verified")
+		return
+	}
+	if !resendVerificationByIP.allow(apiCfg.clientIP(r)) || !resendVerificationByEmail.allow(strings.ToLower(user.Email)) {
+		respondWithError(w, http.StatusTooManyRequests, "Too many verification emails requested, try again later")
+		return
+	}
+
+	err := apiCfg.sendEmailVerification(r.Context(), user)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to send verification email: %v", err))
+		return
+	}
+
+	respondWithJSON(w, http.StatusAccepted, map[string]string{
+		"message": "Verification email sent",
+	})
+}
+
+// useUserToken spends a mailed token within tx, writing the error response
+// and returning false when it is unknown, used or expired.
+func useUserToken(w http.ResponseWriter, r *http.Request, qtx *database.Queries, secret, purpose string) (database.UserToken, bool) {
+	token, err := qtx.GetUserTokenByHash(r.Context(), database.GetUserTokenByHashParams{
+		TokenHash: auth.HashToken(secret),
+		Purpose:   purpose,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusBadRequest, "Invalid or expired token")
+		return database.UserToken{}, false
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Couldn't get token: %v", err))
+		return database.UserToken{}, false
+	}
+
+	used, err := qtx.UseUserToken(r.Context(), token.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to use token: %v", err))
+		return database.UserToken{}, false
+	}
+	if used == 0 {
+		respondWithError(w, http.StatusBadRequest, "Invalid or expired token")
+		return database.UserToken{}, false
+	}
+
+	return token, true
+}
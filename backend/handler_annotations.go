@@ -0,0 +1,401 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Jayant-Verma/rssagg/internal/database"
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+)
+
+const (
+	maxNoteLength             = 10000
+	maxHighlightQuoteLength   = 5000
+	maxHighlightCommentLength = 2000
+)
+
+// postForUser parses the postID URL parameter and makes sure the user can
+// see the post, responding with an error when not.
+func (apiCfg *apiConfig) postForUser(w http.ResponseWriter, r *http.Request, user database.User) (database.Post, bool) {
+	postID, err := uuid.Parse(chi.URLParam(r, "postID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Failed to parse post ID: %v", err))
+		return database.Post{}, false
+	}
+
+	post, err := apiCfg.DB.GetPostForUser(r.Context(), database.GetPostForUserParams{
+		ID:     postID,
+		UserID: user.ID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 404, "Post not found")
+		return database.Post{}, false
+	}
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Couldn't get post: %v", err))
+		return database.Post{}, false
+	}
+	return post, true
+}
+
+func (apiCfg *apiConfig) handlerGetPostAnnotations(w http.ResponseWriter, r *http.Request, user database.User) {
+	post, ok := apiCfg.postForUser(w, r, user)
+	if !ok {
+		return
+	}
+
+	annotations := PostAnnotations{}
+	note, err := apiCfg.DB.GetPostNote(r.Context(), database.GetPostNoteParams{
+		UserID: user.ID,
+		PostID: post.ID,
+	})
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 500, fmt.Sprintf("Couldn't get note: %v", err))
+		return
+	}
+	if err == nil {
+		postNote := databasePostNoteToPostNote(note)
+		annotations.Note = &postNote
+	}
+
+	highlights, err := apiCfg.DB.GetPostHighlights(r.Context(), database.GetPostHighlightsParams{
+		UserID: user.ID,
+		PostID: post.ID,
+	})
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Couldn't get highlights: %v", err))
+		return
+	}
+	annotations.Highlights = databasePostHighlightsToPostHighlights(highlights)
+
+	respondWithJSON(w, 200, annotations)
+}
+
+func (apiCfg *apiConfig) handlerSetPostNote(w http.ResponseWriter, r *http.Request, user database.User) {
+	type parameters struct {
+		Body string `json:"body"`
+	}
+
+	post, ok := apiCfg.postForUser(w, r, user)
+	if !ok {
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Failed to decode request body: %v", err))
+		return
+	}
+
+	body := strings.TrimSpace(params.Body)
+	if body == "" || utf8.RuneCountInString(body) > maxNoteLength {
+		respondWithError(w, 400, fmt.Sprintf("Body must be between 1 and %d characters", maxNoteLength))
+		return
+	}
+
+	note, err := apiCfg.DB.UpsertPostNote(r.Context(), database.UpsertPostNoteParams{
+		UserID: user.ID,
+		PostID: post.ID,
+		Body:   body,
+	})
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to save note: %v", err))
+		return
+	}
+
+	respondWithJSON(w, 200, databasePostNoteToPostNote(note))
+}
+
+func (apiCfg *apiConfig) handlerDeletePostNote(w http.ResponseWriter, r *http.Request, user database.User) {
+	postID, err := uuid.Parse(chi.URLParam(r, "postID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Failed to parse post ID: %v", err))
+		return
+	}
+
+	deleted, err := apiCfg.DB.DeletePostNote(r.Context(), database.DeletePostNoteParams{
+		UserID: user.ID,
+		PostID: postID,
+	})
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to delete note: %v", err))
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, 404, "Note not found")
+		return
+	}
+
+	respondWithJSON(w, 200, map[string]string{
+		"message": "Note deleted successfully",
+	})
+}
+
+func highlightComment(comment *string) (sql.NullString, string) {
+	if comment == nil {
+		return sql.NullString{}, ""
+	}
+	trimmed := strings.TrimSpace(*comment)
+	if utf8.RuneCountInString(trimmed) > maxHighlightCommentLength {
+		return sql.NullString{}, fmt.Sprintf("Comment must be at most %d characters", maxHighlightCommentLength)
+	}
+	return sql.NullString{String: trimmed, Valid: trimmed != ""}, ""
+}
+
+func (apiCfg *apiConfig) handlerCreatePostHighlight(w http.ResponseWriter, r *http.Request, user database.User) {
+	type parameters struct {
+		Quote       string  `json:"quote"`
+		StartOffset *int32  `json:"start_offset"`
+		EndOffset   *int32  `json:"end_offset"`
+		Comment     *string `json:"comment"`
+	}
+
+	post, ok := apiCfg.postForUser(w, r, user)
+	if !ok {
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Failed to decode request body: %v", err))
+		return
+	}
+
+	if strings.TrimSpace(params.Quote) == "" || utf8.RuneCountInString(params.Quote) > maxHighlightQuoteLength {
+		respondWithError(w, 400, fmt.Sprintf("Quote must be between 1 and %d characters", maxHighlightQuoteLength))
+		return
+	}
+
+	startOffset, endOffset := sql.NullInt32{}, sql.NullInt32{}
+	if params.StartOffset != nil || params.EndOffset != nil {
+		if params.StartOffset == nil || params.EndOffset == nil || *params.StartOffset < 0 || *params.EndOffset <= *params.StartOffset {
+			respondWithError(w, 400, "start_offset and end_offset must be given together, with end_offset after start_offset")
+			return
+		}
+		startOffset = sql.NullInt32{Int32: *params.StartOffset, Valid: true}
+		endOffset = sql.NullInt32{Int32: *params.EndOffset, Valid: true}
+	}
+
+	comment, msg := highlightComment(params.Comment)
+	if msg != "" {
+		respondWithError(w, 400, msg)
+		return
+	}
+
+	highlight, err := apiCfg.DB.CreatePostHighlight(r.Context(), database.CreatePostHighlightParams{
+		ID:          uuid.New(),
+		CreatedAt:   time.Now().UTC(),
+		UpdatedAt:   time.Now().UTC(),
+		UserID:      user.ID,
+		PostID:      post.ID,
+		Quote:       params.Quote,
+		StartOffset: startOffset,
+		EndOffset:   endOffset,
+		Comment:     comment,
+	})
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to create highlight: %v", err))
+		return
+	}
+
+	respondWithJSON(w, 201, databasePostHighlightToPostHighlight(highlight))
+}
+
+// handlerUpdatePostHighlight changes the comment on a highlight; the quoted
+// passage itself is fixed once created.
+func (apiCfg *apiConfig) handlerUpdatePostHighlight(w http.ResponseWriter, r *http.Request, user database.User) {
+	type parameters struct {
+		Comment *string `json:"comment"`
+	}
+
+	highlightID, err := uuid.Parse(chi.URLParam(r, "highlightID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Failed to parse highlight ID: %v", err))
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Failed to decode request body: %v", err))
+		return
+	}
+
+	comment, msg := highlightComment(params.Comment)
+	if msg != "" {
+		respondWithError(w, 400, msg)
+		return
+	}
+
+	highlight, err := apiCfg.DB.UpdatePostHighlightComment(r.Context(), database.UpdatePostHighlightCommentParams{
+		ID:      highlightID,
+		UserID:  user.ID,
+		Comment: comment,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 404, "Highlight not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to update highlight: %v", err))
+		return
+	}
+
+	respondWithJSON(w, 200, databasePostHighlightToPostHighlight(highlight))
+}
+
+func (apiCfg *apiConfig) handlerDeletePostHighlight(w http.ResponseWriter, r *http.Request, user database.User) {
+	highlightID, err := uuid.Parse(chi.URLParam(r, "highlightID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Failed to parse highlight ID: %v", err))
+		return
+	}
+
+	deleted, err := apiCfg.DB.DeletePostHighlight(r.Context(), database.DeletePostHighlightParams{
+		ID:     highlightID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to delete highlight: %v", err))
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, 404, "Highlight not found")
+		return
+	}
+
+	respondWithJSON(w, 200, map[string]string{
+		"message": "Highlight deleted successfully",
+	})
+}
+
+// annotatedPosts collects the user's notes and highlights grouped by post,
+// most recently annotated posts first.
+func (apiCfg *apiConfig) annotatedPosts(ctx context.Context, user database.User) ([]AnnotatedPost, error) {
+	posts, err := apiCfg.DB.GetAnnotatedPostsForUser(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	notes, err := apiCfg.DB.GetNotesForUser(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	highlights, err := apiCfg.DB.GetHighlightsForUser(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	notesByPost := map[uuid.UUID]PostNote{}
+	for _, note := range notes {
+		notesByPost[note.PostID] = databasePostNoteToPostNote(note)
+	}
+	highlightsByPost := map[uuid.UUID][]PostHighlight{}
+	for _, highlight := range highlights {
+		highlightsByPost[highlight.PostID] = append(highlightsByPost[highlight.PostID], databasePostHighlightToPostHighlight(highlight))
+	}
+
+	annotated := []AnnotatedPost{}
+	for _, post := range posts {
+		item := AnnotatedPost{
+			PostAnnotations: PostAnnotations{Highlights: highlightsByPost[post.ID]},
+			PostID:          post.ID,
+			Title:           post.Title,
+			URL:             post.Url,
+			FeedID:          post.FeedID,
+			FeedName:        post.FeedName,
+			PublishedAt:     post.PublishedAt,
+			LastAnnotatedAt: post.LastAnnotatedAt,
+		}
+		if item.Highlights == nil {
+			item.Highlights = []PostHighlight{}
+		}
+		if note, ok := notesByPost[post.ID]; ok {
+			item.Note = &note
+		}
+		annotated = append(annotated, item)
+	}
+	return annotated, nil
+}
+
+func (apiCfg *apiConfig) handlerGetAnnotations(w http.ResponseWriter, r *http.Request, user database.User) {
+	annotated, err := apiCfg.annotatedPosts(r.Context(), user)
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Couldn't get annotations: %v", err))
+		return
+	}
+
+	respondWithJSON(w, 200, annotated)
+}
+
+func (apiCfg *apiConfig) handlerExportAnnotations(w http.ResponseWriter, r *http.Request, user database.User) {
+	annotated, err := apiCfg.annotatedPosts(r.Context(), user)
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Couldn't get annotations: %v", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="annotations.md"`)
+	w.WriteHeader(200)
+	w.Write([]byte(annotationsToMarkdown(annotated)))
+}
+
+// annotationsToMarkdown renders one section per post: a linked title, the
+// note as a paragraph and each highlight as a block quote followed by its
+// comment.
+func annotationsToMarkdown(annotated []AnnotatedPost) string {
+	var b strings.Builder
+	b.WriteString("# Annotations\n")
+
+	for _, post := range annotated {
+		fmt.Fprintf(&b, "\n## [%s](%s)\n\n", markdownEscaper.Replace(post.Title), markdownURLEscaper.Replace(post.URL))
+		fmt.Fprintf(&b, "_%s, %s_\n", markdownEscaper.Replace(post.FeedName), post.PublishedAt.Format("January 2, 2006"))
+
+		if post.Note != nil {
+			fmt.Fprintf(&b, "\n%s\n", post.Note.Body)
+		}
+		for _, highlight := range post.Highlights {
+			b.WriteString("\n")
+			for _, line := range strings.Split(strings.TrimSpace(highlight.Quote), "\n") {
+				fmt.Fprintf(&b, "> %s\n", line)
+			}
+			if highlight.Comment != nil {
+				fmt.Fprintf(&b, "\n%s\n", *highlight.Comment)
+			}
+		}
+	}
+	return b.String()
+}
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"[", `\[`,
+	"]", `\]`,
+	"*", `\*`,
+	"_", `\_`,
+)
+
+// markdownURLEscaper percent-encodes the characters that would end a link
+// destination early or let the URL break out of it.
+var markdownURLEscaper = strings.NewReplacer(
+	" ", "%20",
+	"(", "%28",
+	")", "%29",
+	"<", "%3C",
+	">", "%3E",
+	"\n", "%0A",
+	"\r", "%0D",
+)
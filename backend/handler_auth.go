@@ -1,8 +1,13 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log"
 	"net/http"
 	"time"
 
@@ -59,6 +64,8 @@ func (apiCfg *apiConfig) handlerRegisterUser(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	sendInBackground("verification email", user, apiCfg.sendEmailVerification)
+
 	respondWithJSON(w, http.StatusCreated, databaseUserToUser(user))
 }
 
@@ -87,21 +94,141 @@ func (apiCfg *apiConfig) handlerLoginUser(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	token, err := auth.GetJWT(user.ID)
+	tokens, err := apiCfg.startSession(r, user)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Failed to generate token")
 		return
 	}
 
-	http.SetCookie(w, &http.Cookie{
-		Name:     "token",
-		Value:    token,
-		Path:     "/",                                // ✅ Cookie available on all routes
-		Expires:  time.Now().Add(7 * 24 * time.Hour), // Expires in 7 days
-		HttpOnly: true,                               // ✅ Middleware can read it
-		Secure:   false,                              // ✅ Set to `true` in production (for HTTPS)
-		SameSite: http.SameSiteLaxMode,               // Helps with security
-	})
+	apiCfg.setSessionCookies(w, tokens)
+
+	respondWithJSON(w, http.StatusOK, tokens)
+}
+
+// handlerRefreshToken exchanges a refresh token for a new access token and
+// the next refresh token. Each refresh token works once: presenting one
+// again means it was copied, so the whole session is revoked.
+//
+// Browsers may leave the body empty and send the refresh token cookie
+// instead, together with the session's CSRF token.
+func (apiCfg *apiConfig) handlerRefreshToken(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	var params parameters
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil && !errors.Is(err, io.EOF) {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	fromCookie := false
+	if params.RefreshToken == "" {
+		cookie, err := r.Cookie(refreshCookieName)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "refresh_token is required")
+			return
+		}
+		params.RefreshToken = cookie.Value
+		fromCookie = true
+	}
+
+	stored, err := apiCfg.DB.GetRefreshTokenByHash(r.Context(), auth.HashToken(params.RefreshToken))
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusUnauthorized, "Invalid refresh token")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Couldn't get refresh token: %v", err))
+		return
+	}
+
+	session, err := apiCfg.DB.GetSessionByID(r.Context(), stored.SessionID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Couldn't get session: %v", err))
+		return
+	}
+	if session.RevokedAt.Valid {
+		respondWithError(w, http.StatusUnauthorized, "Session has been revoked")
+		return
+	}
+	if fromCookie && !validCSRFToken(r, session.ID) {
+		respondWithError(w, http.StatusForbidden, "Missing or invalid CSRF token")
+		return
+	}
+	if stored.UsedAt.Valid {
+		apiCfg.revokeReusedSession(r.Context(), session)
+		respondWithError(w, http.StatusUnauthorized, "Refresh token was already used, session revoked")
+		return
+	}
+	if !stored.ExpiresAt.After(time.Now().UTC()) {
+		respondWithError(w, http.StatusUnauthorized, "Refresh token has expired")
+		return
+	}
+
+	tx, err := apiCfg.Conn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to start transaction: %v", err))
+		return
+	}
+	defer tx.Rollback()
+	qtx := apiCfg.DB.WithTx(tx)
+
+	used, err := qtx.UseRefreshToken(r.Context(), stored.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to use refresh token: %v", err))
+		return
+	}
+	if used == 0 {
+		// Another request exchanged the same token in the meantime.
+		tx.Rollback()
+		apiCfg.revokeReusedSession(r.Context(), session)
+		respondWithError(w, http.StatusUnauthorized, "Refresh token was already used, session revoked")
+		return
+	}
+
+	tokens, err := apiCfg.issueTokens(r.Context(), qtx, session)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to issue tokens: %v", err))
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to commit transaction: %v", err))
+		return
+	}
 
-	respondWithJSON(w, http.StatusOK, map[string]string{"token": token})
+	if fromCookie {
+		apiCfg.setSessionCookies(w, tokens)
+	}
+	respondWithJSON(w, http.StatusOK, tokens)
+}
+
+func (apiCfg *apiConfig) revokeReusedSession(ctx context.Context, session database.Session) {
+	log.Printf("Refresh token reused for session %v of user %v, revoking it", session.ID, session.UserID)
+	err := apiCfg.DB.RevokeSession(ctx, session.ID)
+	if err != nil {
+		log.Printf("Failed to revoke session %v: %v", session.ID, err)
+	}
+}
+
+// handlerLogout ends the session of the access token, so neither it nor any
+// refresh token of the session can be used again.
+func (apiCfg *apiConfig) handlerLogout(w http.ResponseWriter, r *http.Request, user database.User) {
+	info, _ := authFromContext(r.Context())
+	if !info.SessionID.Valid {
+		respondWithError(w, http.StatusBadRequest, "Only login sessions can be logged out")
+		return
+	}
+
+	err := apiCfg.DB.RevokeSession(r.Context(), info.SessionID.UUID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to log out: %v", err))
+		return
+	}
+
+	apiCfg.clearSessionCookies(w)
+
+	respondWithJSON(w, http.StatusOK, map[string]string{
+		"message": "Logged out successfully",
+	})
 }
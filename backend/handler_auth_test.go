@@ -0,0 +1,93 @@
+package main
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Jayant-Verma/rssagg/internal/auth"
+	"github.com/Jayant-Verma/rssagg/internal/database"
+	"github.com/google/uuid"
+)
+
+func TestHandlerRefreshToken(t *testing.T) {
+	tests := []struct {
+		name        string
+		setup       func(store *fakeStore, token *database.RefreshToken)
+		wantCode    int
+		wantRevoked bool
+	}{
+		{
+			name:     "valid token is exchanged",
+			wantCode: http.StatusOK,
+		},
+		{
+			name: "reused token revokes the session",
+			setup: func(store *fakeStore, token *database.RefreshToken) {
+				token.UsedAt = sql.NullTime{Time: time.Now().UTC().Add(-time.Minute), Valid: true}
+			},
+			wantCode:    http.StatusUnauthorized,
+			wantRevoked: true,
+		},
+		{
+			name: "concurrent exchange revokes the session",
+			setup: func(store *fakeStore, token *database.RefreshToken) {
+				id := token.ID
+				store.beforeUseRefreshToken = func() {
+					store.mu.Lock()
+					defer store.mu.Unlock()
+					used := store.refreshTokens[id]
+					used.UsedAt = sql.NullTime{Time: time.Now().UTC(), Valid: true}
+					store.refreshTokens[id] = used
+				}
+			},
+			wantCode:    http.StatusUnauthorized,
+			wantRevoked: true,
+		},
+		{
+			name: "expired token is rejected",
+			setup: func(store *fakeStore, token *database.RefreshToken) {
+				token.ExpiresAt = time.Now().UTC().Add(-time.Minute)
+			},
+			wantCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newFakeStore()
+			apiCfg := newTestAPIConfig(t, store)
+			_, session := addTestSession(store)
+
+			secret := "refresh-secret"
+			token := database.RefreshToken{
+				ID:        uuid.New(),
+				CreatedAt: time.Now().UTC(),
+				SessionID: session.ID,
+				TokenHash: auth.HashToken(secret),
+				ExpiresAt: time.Now().UTC().Add(time.Hour),
+			}
+			if tt.setup != nil {
+				tt.setup(store, &token)
+			}
+			store.refreshTokens[token.ID] = token
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/auth/refresh", strings.NewReader(`{"refresh_token":"`+secret+`"}`))
+			w := httptest.NewRecorder()
+			apiCfg.handlerRefreshToken(w, req)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantCode, w.Body.String())
+			}
+			if revoked := store.sessions[session.ID].RevokedAt.Valid; revoked != tt.wantRevoked {
+				t.Errorf("session revoked = %v, want %v", revoked, tt.wantRevoked)
+			}
+			if tt.wantCode == http.StatusOK && !store.refreshTokens[token.ID].UsedAt.Valid {
+				t.Errorf("exchanged refresh token was not marked used")
+			}
+		})
+	}
+}
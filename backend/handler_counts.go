@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/Jayant-Verma/rssagg/internal/database"
+	"github.com/google/uuid"
+)
+
+type FeedFollowUnreadCount struct {
+	FeedFollowID uuid.UUID  `json:"feed_follow_id"`
+	FeedID       uuid.UUID  `json:"feed_id"`
+	FolderID     *uuid.UUID `json:"folder_id"`
+	Muted        bool       `json:"muted"`
+	Unread       int64      `json:"unread"`
+}
+
+type FolderUnreadCount struct {
+	FolderID uuid.UUID `json:"folder_id"`
+	Unread   int64     `json:"unread"`
+}
+
+type SavedSearchUnreadCount struct {
+	SavedSearchID uuid.UUID `json:"saved_search_id"`
+	Unread        int64     `json:"unread"`
+}
+
+type UnreadCounts struct {
+	Total         int64                    `json:"total"`
+	FeedFollows   []FeedFollowUnreadCount  `json:"feed_follows"`
+	Folders       []FolderUnreadCount      `json:"folders"`
+	SavedSearches []SavedSearchUnreadCount `json:"saved_searches"`
+}
+
+// handlerGetUnreadCounts returns unread badge counts. Folder counts include
+// their subfolders; muted feed follows are reported but left out of the
+// folder and total counts. Saved searches only repeat posts already counted
+// under their feeds, so they are not part of the total either.
+func (apiCfg *apiConfig) handlerGetUnreadCounts(w http.ResponseWriter, r *http.Request, user database.User) {
+	rows, err := apiCfg.DB.GetUnreadCountsForUser(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Couldn't get unread counts: %v", err))
+		return
+	}
+
+	folders, err := apiCfg.DB.GetFolders(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Couldn't get folders: %v", err))
+		return
+	}
+
+	savedSearches, err := apiCfg.DB.GetSavedSearchUnreadCounts(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Couldn't get saved search counts: %v", err))
+		return
+	}
+
+	parents := map[uuid.UUID]uuid.NullUUID{}
+	folderUnread := map[uuid.UUID]int64{}
+	for _, folder := range folders {
+		parents[folder.ID] = folder.ParentID
+		folderUnread[folder.ID] = 0
+	}
+
+	counts := UnreadCounts{
+		FeedFollows:   []FeedFollowUnreadCount{},
+		Folders:       []FolderUnreadCount{},
+		SavedSearches: []SavedSearchUnreadCount{},
+	}
+	for _, row := range rows {
+		unread := max(row.Unread, 0)
+
+		count := FeedFollowUnreadCount{
+			FeedFollowID: row.ID,
+			FeedID:       row.FeedID,
+			Muted:        row.Muted,
+			Unread:       unread,
+		}
+		if row.FolderID.Valid {
+			count.FolderID = &row.FolderID.UUID
+		}
+		counts.FeedFollows = append(counts.FeedFollows, count)
+
+		if row.Muted {
+			continue
+		}
+		counts.Total += unread
+		if row.FolderID.Valid {
+			folderUnread[row.FolderID.UUID] += unread
+			if parent := parents[row.FolderID.UUID]; parent.Valid {
+				folderUnread[parent.UUID] += unread
+			}
+		}
+	}
+
+	for _, folder := range folders {
+		counts.Folders = append(counts.Folders, FolderUnreadCount{
+			FolderID: folder.ID,
+			Unread:   folderUnread[folder.ID],
+		})
+	}
+
+	for _, savedSearch := range savedSearches {
+		counts.SavedSearches = append(counts.SavedSearches, SavedSearchUnreadCount{
+			SavedSearchID: savedSearch.ID,
+			Unread:        savedSearch.Unread,
+		})
+	}
+
+	respondWithJSON(w, 200, counts)
+}
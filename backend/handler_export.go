@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/Jayant-Verma/rssagg/internal/database"
+)
+
+// handlerExportAccount downloads all of the user's data as JSON, for
+// keeping a copy before deleting the account or moving elsewhere.
+func (apiCfg *apiConfig) handlerExportAccount(w http.ResponseWriter, r *http.Request, user database.User) {
+	export, err := apiCfg.accountExport(r.Context(), user)
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Couldn't export account: %v", err))
+		return
+	}
+
+	dat, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to marshal export: %v", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", `attachment; filename="rssagg-export.json"`)
+	w.WriteHeader(200)
+	w.Write(dat)
+}
+
+func (apiCfg *apiConfig) accountExport(ctx context.Context, user database.User) (AccountExport, error) {
+	now := time.Now().UTC()
+	export := AccountExport{
+		ExportedAt: now,
+		User:       databaseUserToUser(user),
+	}
+
+	follows, err := apiCfg.DB.GetFeedFollowsWithFeeds(ctx, user.ID)
+	if err != nil {
+		return AccountExport{}, fmt.Errorf("feed follows: %w", err)
+	}
+	export.FeedFollows = databaseFeedFollowRowsToFeedFollowsWithFeeds(follows)
+
+	folders, err := apiCfg.DB.GetFolders(ctx, user.ID)
+	if err != nil {
+		return AccountExport{}, fmt.Errorf("folders: %w", err)
+	}
+	export.Folders = databaseFoldersToFolders(folders)
+
+	tags, err := apiCfg.DB.GetTagsWithCounts(ctx, user.ID)
+	if err != nil {
+		return AccountExport{}, fmt.Errorf("tags: %w", err)
+	}
+	export.Tags = databaseTagRowsToTagsWithCounts(tags)
+
+	starred, err := apiCfg.DB.GetStarredPostsForUser(ctx, database.GetStarredPostsForUserParams{
+		UserID: user.ID,
+		Limit:  math.MaxInt32,
+	})
+	if err != nil {
+		return AccountExport{}, fmt.Errorf("starred posts: %w", err)
+	}
+	export.StarredPosts = databaseStarredPostRowsToPosts(starred)
+
+	export.Annotations, err = apiCfg.annotatedPosts(ctx, user)
+	if err != nil {
+		return AccountExport{}, fmt.Errorf("annotations: %w", err)
+	}
+
+	savedSearches, err := apiCfg.DB.GetSavedSearches(ctx, user.ID)
+	if err != nil {
+		return AccountExport{}, fmt.Errorf("saved searches: %w", err)
+	}
+	export.SavedSearches = databaseSavedSearchesToSavedSearches(savedSearches)
+
+	rules, err := apiCfg.DB.GetFilterRules(ctx, user.ID)
+	if err != nil {
+		return AccountExport{}, fmt.Errorf("filter rules: %w", err)
+	}
+	export.FilterRules = databaseFilterRuleRowsToFilterRules(rules)
+
+	tokens, err := apiCfg.DB.GetPersonalAccessTokens(ctx, user.ID)
+	if err != nil {
+		return AccountExport{}, fmt.Errorf("personal access tokens: %w", err)
+	}
+	export.PersonalAccessTokens = databasePersonalAccessTokensToPersonalAccessTokens(tokens)
+
+	opmlFollows, err := apiCfg.DB.GetFeedFollowsForExport(ctx, user.ID)
+	if err != nil {
+		return AccountExport{}, fmt.Errorf("OPML: %w", err)
+	}
+	opml, err := xml.MarshalIndent(buildOPML(user, folders, opmlFollows, now), "", "  ")
+	if err != nil {
+		return AccountExport{}, fmt.Errorf("OPML: %w", err)
+	}
+	export.OPML = xml.Header + string(opml)
+
+	return export, nil
+}
@@ -1,12 +1,16 @@
 package main
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
-	"time"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/Jayant-Verma/rssagg/internal/database"
+	"github.com/go-chi/chi"
 	"github.com/google/uuid"
 )
 
@@ -24,20 +28,69 @@ func (apiCfg *apiConfig) handlerCreateFeed(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	feed, err := apiCfg.DB.CreateFeed(r.Context(), database.CreateFeedParams{
-		ID:        uuid.New(),
-		CreatedAt: time.Now().UTC(),
-		UpdatedAt: time.Now().UTC(),
-		Name:      params.Name,
-		UserID:    user.ID,
-		Url:       params.URL,
-	})
+	sub, err := apiCfg.subscribeToFeed(r.Context(), user, params.Name, params.URL)
+	var subErr *subscribeError
+	if errors.As(err, &subErr) {
+		respondWithSubscribeError(w, subErr)
+		return
+	}
 	if err != nil {
 		respondWithError(w, 500, fmt.Sprintf("Failed to create feed: %v", err))
 		return
 	}
 
-	respondWithJSON(w, 201, databaseFeedToFeed(feed))
+	if !sub.Created {
+		respondWithJSON(w, 200, databaseFeedToFeed(sub.Feed))
+		return
+	}
+	respondWithJSON(w, 201, databaseFeedToFeed(sub.Feed))
+}
+
+func (apiCfg *apiConfig) handlerPreviewFeed(w http.ResponseWriter, r *http.Request, user database.User) {
+	type parameters struct {
+		URL string `json:"url"`
+	}
+	decoder := json.NewDecoder(r.Body)
+
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Failed to decode request body: %v", err))
+		return
+	}
+
+	// Previews go through the same URL checks as subscribing.
+	feedURL, err := normalizeFeedURL(params.URL)
+	if err != nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
+
+	dat, resp, err := fetchURL(r.Context(), feedURL)
+	if err != nil {
+		respondWithError(w, http.StatusUnprocessableEntity, fmt.Sprintf("Failed to fetch URL: %v", err))
+		return
+	}
+
+	if isHTML(resp, dat) {
+		type webPageResponse struct {
+			Error      string          `json:"error"`
+			Candidates []FeedCandidate `json:"candidates"`
+		}
+		respondWithJSON(w, http.StatusUnprocessableEntity, webPageResponse{
+			Error:      "URL points to a web page, not a feed",
+			Candidates: discoverFeeds(r.Context(), resp.Request.URL, dat),
+		})
+		return
+	}
+
+	rssFeed, format, err := parseFeed(dat)
+	if err != nil {
+		respondWithError(w, http.StatusUnprocessableEntity, fmt.Sprintf("Failed to parse feed: %v", err))
+		return
+	}
+
+	respondWithJSON(w, 200, buildFeedPreview(resp.Request.URL.String(), format, rssFeed))
 }
 
 func (apiCfg *apiConfig) handlerGetFeeds(w http.ResponseWriter, r *http.Request) {
@@ -49,3 +102,210 @@ func (apiCfg *apiConfig) handlerGetFeeds(w http.ResponseWriter, r *http.Request)
 
 	respondWithJSON(w, 201, databaseFeedsToFeeds(feeds))
 }
+
+// canManageFeed reports whether user may change or delete feed.
+func canManageFeed(user database.User, feed database.Feed) bool {
+	return feed.UserID == user.ID || user.IsAdmin
+}
+
+func (apiCfg *apiConfig) feedFromURLParam(w http.ResponseWriter, r *http.Request, user database.User) (database.Feed, bool) {
+	feedID, err := uuid.Parse(chi.URLParam(r, "feedID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Failed to parse feed ID: %v", err))
+		return database.Feed{}, false
+	}
+
+	feed, err := apiCfg.DB.GetFeedByID(r.Context(), feedID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 404, "Feed not found")
+		return database.Feed{}, false
+	}
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Couldn't get feed: %v", err))
+		return database.Feed{}, false
+	}
+
+	if !canManageFeed(user, feed) {
+		respondWithError(w, 403, "Only the feed owner can manage this feed")
+		return database.Feed{}, false
+	}
+	return feed, true
+}
+
+func (apiCfg *apiConfig) handlerUpdateFeed(w http.ResponseWriter, r *http.Request, user database.User) {
+	type parameters struct {
+		Name   *string `json:"name"`
+		URL    *string `json:"url"`
+		Paused *bool   `json:"paused"`
+	}
+
+	feed, ok := apiCfg.feedFromURLParam(w, r, user)
+	if !ok {
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Failed to decode request body: %v", err))
+		return
+	}
+
+	update := database.UpdateFeedParams{
+		ID:            feed.ID,
+		Name:          feed.Name,
+		Url:           feed.Url,
+		Paused:        feed.Paused,
+		LastFetchedAt: feed.LastFetchedAt,
+	}
+
+	if params.Name != nil {
+		name := strings.TrimSpace(*params.Name)
+		if name == "" || utf8.RuneCountInString(name) > maxFeedNameLength {
+			respondWithError(w, 400, fmt.Sprintf("Name must be between 1 and %d characters", maxFeedNameLength))
+			return
+		}
+		update.Name = name
+	}
+
+	if params.URL != nil {
+		feedURL, err := normalizeFeedURL(*params.URL)
+		if err != nil {
+			respondWithError(w, 400, err.Error())
+			return
+		}
+		if feedURL != feed.Url {
+			// Changing the URL changes what every follower reads, so only
+			// admins may do it once others follow the feed.
+			if !user.IsAdmin {
+				_, err := apiCfg.DB.GetEarliestOtherFollower(r.Context(), database.GetEarliestOtherFollowerParams{
+					FeedID: feed.ID,
+					UserID: feed.UserID,
+				})
+				if err == nil {
+					respondWithError(w, 403, "Only admins can change the URL of a feed other users follow")
+					return
+				}
+				if !errors.Is(err, sql.ErrNoRows) {
+					respondWithError(w, 500, fmt.Sprintf("Couldn't get feed followers: %v", err))
+					return
+				}
+			}
+
+			feedURL, _, err = resolveFeedURL(r.Context(), feedURL)
+			var subErr *subscribeError
+			if errors.As(err, &subErr) {
+				respondWithSubscribeError(w, subErr)
+				return
+			}
+			if err != nil {
+				respondWithError(w, 500, fmt.Sprintf("Failed to validate feed URL: %v", err))
+				return
+			}
+
+			existing, err := apiCfg.DB.GetFeedByURL(r.Context(), feedURL)
+			if err == nil && existing.ID != feed.ID {
+				respondWithError(w, 409, "A feed with this URL already exists")
+				return
+			}
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				respondWithError(w, 500, fmt.Sprintf("Couldn't check feed URL: %v", err))
+				return
+			}
+
+			// Fetch the new URL on the next scraper run.
+			update.Url = feedURL
+			update.LastFetchedAt = sql.NullTime{}
+		}
+	}
+
+	if params.Paused != nil {
+		update.Paused = *params.Paused
+	}
+
+	feed, err = apiCfg.DB.UpdateFeed(r.Context(), update)
+	if isUniqueViolation(err) {
+		respondWithError(w, 409, "A feed with this URL already exists")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to update feed: %v", err))
+		return
+	}
+
+	respondWithJSON(w, 200, databaseFeedToFeed(feed))
+}
+
+// handlerDeleteFeed removes a feed. When the owner deletes a feed that other
+// users still follow, ownership passes to the earliest of those followers and
+// only the owner's follow is dropped; admins can force a hard delete with
+// ?force=true, and deleting someone else's feed as an admin is always hard.
+func (apiCfg *apiConfig) handlerDeleteFeed(w http.ResponseWriter, r *http.Request, user database.User) {
+	feed, ok := apiCfg.feedFromURLParam(w, r, user)
+	if !ok {
+		return
+	}
+
+	force := feed.UserID != user.ID || (user.IsAdmin && r.URL.Query().Get("force") == "true")
+
+	tx, err := apiCfg.Conn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to delete feed: %v", err))
+		return
+	}
+	defer tx.Rollback()
+	qtx := apiCfg.DB.WithTx(tx)
+
+	if !force {
+		successorID, err := qtx.GetEarliestOtherFollower(r.Context(), database.GetEarliestOtherFollowerParams{
+			FeedID: feed.ID,
+			UserID: feed.UserID,
+		})
+		if err == nil {
+			_, err = qtx.TransferFeedOwnership(r.Context(), database.TransferFeedOwnershipParams{
+				ID:     feed.ID,
+				UserID: successorID,
+			})
+			if err != nil {
+				respondWithError(w, 500, fmt.Sprintf("Failed to transfer feed: %v", err))
+				return
+			}
+			err = qtx.DeleteFeedFollow(r.Context(), database.DeleteFeedFollowParams{
+				FeedID: feed.ID,
+				UserID: feed.UserID,
+			})
+			if err != nil {
+				respondWithError(w, 500, fmt.Sprintf("Failed to delete feed follow: %v", err))
+				return
+			}
+			if err := tx.Commit(); err != nil {
+				respondWithError(w, 500, fmt.Sprintf("Failed to delete feed: %v", err))
+				return
+			}
+
+			respondWithJSON(w, 200, map[string]string{
+				"message": "Feed is still followed by other users; ownership was transferred and you have unfollowed it",
+			})
+			return
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, 500, fmt.Sprintf("Couldn't get feed followers: %v", err))
+			return
+		}
+	}
+
+	err = qtx.DeleteFeed(r.Context(), feed.ID)
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to delete feed: %v", err))
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to delete feed: %v", err))
+		return
+	}
+
+	respondWithJSON(w, 200, map[string]string{
+		"message": "Feed deleted successfully",
+	})
+}
@@ -1,10 +1,14 @@
 package main
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/Jayant-Verma/rssagg/internal/database"
 	"github.com/go-chi/chi"
@@ -36,29 +40,57 @@ func (apiCfg *apiConfig) handlerCreateFeedFollow(w http.ResponseWriter, r *http.
 		return
 	}
 
+	apiCfg.backfillSavedSearches(r.Context(), user.ID, feedFollow.FeedID)
+
 	respondWithJSON(w, 201, databaseFeedFollowToFeedFollow(feedFollow))
 }
 
 func (apiCfg *apiConfig) handlerGetFeedFollows(w http.ResponseWriter, r *http.Request, user database.User) {
-	feedFollows, err := apiCfg.DB.GetFeedFollows(r.Context(), user.ID)
+	feedFollows, err := apiCfg.DB.GetFeedFollowsWithFeeds(r.Context(), user.ID)
 	if err != nil {
 		respondWithError(w, 500, fmt.Sprintf("Couldn't get feed follows: %v", err))
 		return
 	}
 
-	respondWithJSON(w, 201, databaseFeedFollowsToFeedFollows(feedFollows))
+	respondWithJSON(w, 201, databaseFeedFollowRowsToFeedFollowsWithFeeds(feedFollows))
 }
 
 func (apiCfg *apiConfig) handlerDeleteFeedFollow(w http.ResponseWriter, r *http.Request, user database.User) {
-	feedFollowIDStr := chi.URLParam(r, "feedFollowID")
-	feedFollowID, err := uuid.Parse(feedFollowIDStr)
+	feedFollowID, err := uuid.Parse(chi.URLParam(r, "feedFollowID"))
 	if err != nil {
 		respondWithError(w, 400, fmt.Sprintf("Failed to parse feed follow ID: %v", err))
 		return
 	}
 
+	deleted, err := apiCfg.DB.DeleteFeedFollowByID(r.Context(), database.DeleteFeedFollowByIDParams{
+		ID:     feedFollowID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to delete feed follow: %v", err))
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, 404, "Feed follow not found")
+		return
+	}
+
+	respondWithJSON(w, 200, map[string]string{
+		"message": "Feed follow deleted successfully",
+	})
+}
+
+// handlerUnfollowFeed removes the user's follow of a feed, for clients that
+// only know the feed ID.
+func (apiCfg *apiConfig) handlerUnfollowFeed(w http.ResponseWriter, r *http.Request, user database.User) {
+	feedID, err := uuid.Parse(chi.URLParam(r, "feedID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Failed to parse feed ID: %v", err))
+		return
+	}
+
 	err = apiCfg.DB.DeleteFeedFollow(r.Context(), database.DeleteFeedFollowParams{
-		FeedID: feedFollowID,
+		FeedID: feedID,
 		UserID: user.ID,
 	})
 	if err != nil {
@@ -70,3 +102,96 @@ func (apiCfg *apiConfig) handlerDeleteFeedFollow(w http.ResponseWriter, r *http.
 		"message": "Feed follow deleted successfully",
 	})
 }
+
+var notificationPreferences = map[string]bool{
+	"none":    true,
+	"digest":  true,
+	"instant": true,
+}
+
+func (apiCfg *apiConfig) handlerUpdateFeedFollow(w http.ResponseWriter, r *http.Request, user database.User) {
+	type parameters struct {
+		Title          *string `json:"title"`
+		Priority       *int32  `json:"priority"`
+		Muted          *bool   `json:"muted"`
+		ShowInTimeline *bool   `json:"show_in_timeline"`
+		Notifications  *string `json:"notifications"`
+	}
+
+	feedFollowID, err := uuid.Parse(chi.URLParam(r, "feedFollowID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Failed to parse feed follow ID: %v", err))
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Failed to decode request body: %v", err))
+		return
+	}
+
+	feedFollow, err := apiCfg.DB.GetFeedFollowByID(r.Context(), database.GetFeedFollowByIDParams{
+		ID:     feedFollowID,
+		UserID: user.ID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 404, "Feed follow not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Couldn't get feed follow: %v", err))
+		return
+	}
+
+	update := database.UpdateFeedFollowSettingsParams{
+		ID:             feedFollow.ID,
+		UserID:         user.ID,
+		Title:          feedFollow.Title,
+		Priority:       feedFollow.Priority,
+		Muted:          feedFollow.Muted,
+		ShowInTimeline: feedFollow.ShowInTimeline,
+		Notifications:  feedFollow.Notifications,
+	}
+
+	if params.Title != nil {
+		// An empty title resets the subscription to the feed's own name.
+		title := strings.TrimSpace(*params.Title)
+		if utf8.RuneCountInString(title) > maxFeedNameLength {
+			respondWithError(w, 400, fmt.Sprintf("Title must be at most %d characters", maxFeedNameLength))
+			return
+		}
+		update.Title = sql.NullString{String: title, Valid: title != ""}
+	}
+	if params.Priority != nil {
+		update.Priority = *params.Priority
+	}
+	if params.Muted != nil {
+		update.Muted = *params.Muted
+	}
+	if params.ShowInTimeline != nil {
+		update.ShowInTimeline = *params.ShowInTimeline
+	}
+	if params.Notifications != nil {
+		if !notificationPreferences[*params.Notifications] {
+			respondWithError(w, 400, "Notifications must be one of none, digest or instant")
+			return
+		}
+		update.Notifications = *params.Notifications
+	}
+
+	feedFollow, err = apiCfg.DB.UpdateFeedFollowSettings(r.Context(), update)
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to update feed follow: %v", err))
+		return
+	}
+
+	feed, err := apiCfg.DB.GetFeedByID(r.Context(), feedFollow.FeedID)
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Couldn't get feed: %v", err))
+		return
+	}
+
+	respondWithJSON(w, 200, databaseFeedFollowToFeedFollowWithFeed(feedFollow, feed))
+}
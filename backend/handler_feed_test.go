@@ -0,0 +1,43 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Jayant-Verma/rssagg/internal/database"
+)
+
+func TestHandlerPreviewFeedRejectsUnsafeURLs(t *testing.T) {
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<rss version="2.0"><channel><title>internal</title></channel></rss>`))
+	}))
+	defer internal.Close()
+
+	tests := []struct {
+		name     string
+		url      string
+		wantCode int
+	}{
+		{name: "file scheme", url: "file:///etc/passwd", wantCode: http.StatusBadRequest},
+		{name: "missing host", url: "http://", wantCode: http.StatusBadRequest},
+		{name: "loopback address", url: internal.URL, wantCode: http.StatusUnprocessableEntity},
+		{name: "metadata address", url: "http://169.254.169.254/latest/meta-data/", wantCode: http.StatusUnprocessableEntity},
+	}
+
+	apiCfg := &apiConfig{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := strings.NewReader(`{"url":"` + tt.url + `"}`)
+			req := httptest.NewRequest(http.MethodPost, "/v1/feeds/preview", body)
+			rec := httptest.NewRecorder()
+
+			apiCfg.handlerPreviewFeed(rec, req, database.User{})
+
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantCode, rec.Body.String())
+			}
+		})
+	}
+}
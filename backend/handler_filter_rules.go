@@ -0,0 +1,362 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Jayant-Verma/rssagg/internal/database"
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+)
+
+const (
+	maxFilterRuleNameLength = 255
+	maxFilterPatternLength  = 1000
+	filterRuleApplyBatch    = 500
+)
+
+type filterRuleParameters struct {
+	Name       string     `json:"name"`
+	FeedID     *uuid.UUID `json:"feed_id"`
+	MatchField string     `json:"match_field"`
+	MatchType  string     `json:"match_type"`
+	Pattern    string     `json:"pattern"`
+	Action     string     `json:"action"`
+	Tag        *string    `json:"tag"`
+	Enabled    *bool      `json:"enabled"`
+}
+
+// validateFilterRuleParams checks the parameters and returns a client-facing
+// message when they are invalid.
+func (apiCfg *apiConfig) validateFilterRuleParams(ctx context.Context, params *filterRuleParameters) (string, error) {
+	params.Name = strings.TrimSpace(params.Name)
+	if params.Name == "" || utf8.RuneCountInString(params.Name) > maxFilterRuleNameLength {
+		return fmt.Sprintf("Name must be between 1 and %d characters", maxFilterRuleNameLength), nil
+	}
+	if !filterMatchFields[params.MatchField] {
+		return "match_field must be one of title, content, text, author or category", nil
+	}
+	if params.MatchType != filterMatchKeyword && params.MatchType != filterMatchRegex {
+		return "match_type must be keyword or regex", nil
+	}
+	if params.Pattern == "" || utf8.RuneCountInString(params.Pattern) > maxFilterPatternLength {
+		return fmt.Sprintf("Pattern must be between 1 and %d characters", maxFilterPatternLength), nil
+	}
+	if params.MatchType == filterMatchRegex {
+		if _, err := regexp.Compile(params.Pattern); err != nil {
+			return fmt.Sprintf("Pattern is not a valid regular expression: %v", err), nil
+		}
+	}
+	if !filterActions[params.Action] {
+		return "action must be one of hide, mark_read, star or tag", nil
+	}
+
+	if params.Action == filterActionTag {
+		if params.Tag == nil {
+			return "tag is required for the tag action", nil
+		}
+		tag, msg := cleanTagName(*params.Tag)
+		if msg != "" {
+			return msg, nil
+		}
+		params.Tag = &tag
+	} else {
+		params.Tag = nil
+	}
+
+	if params.FeedID != nil {
+		_, err := apiCfg.DB.GetFeedByID(ctx, *params.FeedID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return "Feed not found", nil
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	return "", nil
+}
+
+func nullString(s *string) sql.NullString {
+	if s == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: *s, Valid: true}
+}
+
+func (apiCfg *apiConfig) handlerCreateFilterRule(w http.ResponseWriter, r *http.Request, user database.User) {
+	decoder := json.NewDecoder(r.Body)
+
+	params := filterRuleParameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Failed to decode request body: %v", err))
+		return
+	}
+
+	msg, err := apiCfg.validateFilterRuleParams(r.Context(), &params)
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Couldn't validate filter rule: %v", err))
+		return
+	}
+	if msg != "" {
+		respondWithError(w, 400, msg)
+		return
+	}
+
+	enabled := true
+	if params.Enabled != nil {
+		enabled = *params.Enabled
+	}
+
+	rule, err := apiCfg.DB.CreateFilterRule(r.Context(), database.CreateFilterRuleParams{
+		ID:         uuid.New(),
+		CreatedAt:  time.Now().UTC(),
+		UpdatedAt:  time.Now().UTC(),
+		UserID:     user.ID,
+		FeedID:     nullUUID(params.FeedID),
+		Name:       params.Name,
+		MatchField: params.MatchField,
+		MatchType:  params.MatchType,
+		Pattern:    params.Pattern,
+		Action:     params.Action,
+		Tag:        nullString(params.Tag),
+		Enabled:    enabled,
+	})
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to create filter rule: %v", err))
+		return
+	}
+
+	respondWithJSON(w, 201, databaseFilterRuleToFilterRule(rule, 0))
+}
+
+func (apiCfg *apiConfig) handlerGetFilterRules(w http.ResponseWriter, r *http.Request, user database.User) {
+	rules, err := apiCfg.DB.GetFilterRules(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Couldn't get filter rules: %v", err))
+		return
+	}
+
+	respondWithJSON(w, 200, databaseFilterRuleRowsToFilterRules(rules))
+}
+
+// handlerUpdateFilterRule replaces a rule. Changing what a rule matches or
+// what it does resets its match count, so applying it again reaches posts
+// it matched before.
+func (apiCfg *apiConfig) handlerUpdateFilterRule(w http.ResponseWriter, r *http.Request, user database.User) {
+	ruleID, err := uuid.Parse(chi.URLParam(r, "filterRuleID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Failed to parse filter rule ID: %v", err))
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := filterRuleParameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Failed to decode request body: %v", err))
+		return
+	}
+
+	msg, err := apiCfg.validateFilterRuleParams(r.Context(), &params)
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Couldn't validate filter rule: %v", err))
+		return
+	}
+	if msg != "" {
+		respondWithError(w, 400, msg)
+		return
+	}
+
+	tx, err := apiCfg.Conn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to update filter rule: %v", err))
+		return
+	}
+	defer tx.Rollback()
+	qtx := apiCfg.DB.WithTx(tx)
+
+	existing, err := qtx.GetFilterRuleByID(r.Context(), database.GetFilterRuleByIDParams{
+		ID:     ruleID,
+		UserID: user.ID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 404, "Filter rule not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Couldn't get filter rule: %v", err))
+		return
+	}
+
+	enabled := existing.Enabled
+	if params.Enabled != nil {
+		enabled = *params.Enabled
+	}
+
+	rule, err := qtx.UpdateFilterRule(r.Context(), database.UpdateFilterRuleParams{
+		ID:         ruleID,
+		UserID:     user.ID,
+		FeedID:     nullUUID(params.FeedID),
+		Name:       params.Name,
+		MatchField: params.MatchField,
+		MatchType:  params.MatchType,
+		Pattern:    params.Pattern,
+		Action:     params.Action,
+		Tag:        nullString(params.Tag),
+		Enabled:    enabled,
+	})
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to update filter rule: %v", err))
+		return
+	}
+
+	if existing.FeedID != rule.FeedID || existing.MatchField != rule.MatchField ||
+		existing.MatchType != rule.MatchType || existing.Pattern != rule.Pattern ||
+		existing.Action != rule.Action || existing.Tag != rule.Tag {
+		err = qtx.ClearFilterRuleMatches(r.Context(), rule.ID)
+		if err != nil {
+			respondWithError(w, 500, fmt.Sprintf("Failed to reset filter rule matches: %v", err))
+			return
+		}
+	}
+
+	matchCount, err := qtx.CountFilterRuleMatches(r.Context(), rule.ID)
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Couldn't count filter rule matches: %v", err))
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to update filter rule: %v", err))
+		return
+	}
+
+	respondWithJSON(w, 200, databaseFilterRuleToFilterRule(rule, matchCount))
+}
+
+func (apiCfg *apiConfig) handlerDeleteFilterRule(w http.ResponseWriter, r *http.Request, user database.User) {
+	ruleID, err := uuid.Parse(chi.URLParam(r, "filterRuleID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Failed to parse filter rule ID: %v", err))
+		return
+	}
+
+	deleted, err := apiCfg.DB.DeleteFilterRule(r.Context(), database.DeleteFilterRuleParams{
+		ID:     ruleID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to delete filter rule: %v", err))
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, 404, "Filter rule not found")
+		return
+	}
+
+	respondWithJSON(w, 200, map[string]string{
+		"message": "Filter rule deleted successfully",
+	})
+}
+
+// filterRulesApplying holds the IDs of rules being applied in the
+// background, so a rule isn't run over the same posts twice at once.
+var filterRulesApplying sync.Map
+
+// handlerApplyFilterRule runs a rule over the posts already stored for the
+// feeds the user follows, newest first. It works on disabled rules too, so a
+// rule can be tried out before it's switched on for new posts.
+func (apiCfg *apiConfig) handlerApplyFilterRule(w http.ResponseWriter, r *http.Request, user database.User) {
+	ruleID, err := uuid.Parse(chi.URLParam(r, "filterRuleID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Failed to parse filter rule ID: %v", err))
+		return
+	}
+
+	dbRule, err := apiCfg.DB.GetFilterRuleByID(r.Context(), database.GetFilterRuleByIDParams{
+		ID:     ruleID,
+		UserID: user.ID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 404, "Filter rule not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Couldn't get filter rule: %v", err))
+		return
+	}
+
+	rule, err := compileFilterRule(dbRule)
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Couldn't compile filter rule: %v", err))
+		return
+	}
+
+	if _, running := filterRulesApplying.LoadOrStore(rule.ID, true); running {
+		respondWithError(w, 409, "Filter rule is already being applied")
+		return
+	}
+
+	// A rule can match thousands of stored posts, so it runs in the
+	// background and the client reads the rule's match count for progress.
+	go func() {
+		defer filterRulesApplying.Delete(rule.ID)
+		matched, err := apiCfg.applyFilterRuleToStoredPosts(context.Background(), rule)
+		if err != nil {
+			log.Printf("Failed to apply filter rule %s: %v", rule.ID, err)
+			return
+		}
+		log.Printf("Filter rule %s applied, %v new matches", rule.ID, matched)
+	}()
+
+	respondWithJSON(w, http.StatusAccepted, map[string]string{
+		"message": "Filter rule is being applied",
+	})
+}
+
+func (apiCfg *apiConfig) applyFilterRuleToStoredPosts(ctx context.Context, rule filterRule) (int64, error) {
+	var matched int64
+	var cursor *postCursor
+	for {
+		posts, err := apiCfg.DB.GetPostsForFilterRule(ctx, database.GetPostsForFilterRuleParams{
+			UserID:            rule.UserID,
+			FeedID:            rule.FeedID,
+			BeforePublishedAt: cursorTime(cursor),
+			BeforeID:          cursorID(cursor),
+			Limit:             filterRuleApplyBatch,
+		})
+		if err != nil {
+			return matched, err
+		}
+
+		for _, post := range posts {
+			if !rule.matches(post) {
+				continue
+			}
+			isNew, err := applyFilterRule(ctx, apiCfg.Conn, apiCfg.DB, rule, post.ID)
+			if err != nil {
+				return matched, err
+			}
+			if isNew {
+				matched++
+			}
+		}
+
+		if len(posts) < filterRuleApplyBatch {
+			return matched, nil
+		}
+		last := posts[len(posts)-1]
+		cursor = &postCursor{PublishedAt: last.PublishedAt, ID: last.ID}
+	}
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Jayant-Verma/rssagg/internal/database"
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+)
+
+const maxFolderNameLength = 255
+
+type folderParameters struct {
+	Name     string     `json:"name"`
+	ParentID *uuid.UUID `json:"parent_id"`
+}
+
+// validateFolderParams checks the name and makes sure the parent is one of
+// the user's top-level folders, since folders only nest one level deep.
+// It returns a client-facing message when the parameters are invalid.
+func (apiCfg *apiConfig) validateFolderParams(ctx context.Context, user database.User, params *folderParameters) (string, error) {
+	params.Name = strings.TrimSpace(params.Name)
+	if params.Name == "" || utf8.RuneCountInString(params.Name) > maxFolderNameLength {
+		return fmt.Sprintf("Name must be between 1 and %d characters", maxFolderNameLength), nil
+	}
+
+	if params.ParentID == nil {
+		return "", nil
+	}
+
+	parent, err := apiCfg.DB.GetFolderByID(ctx, database.GetFolderByIDParams{
+		ID:     *params.ParentID,
+		UserID: user.ID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return "Parent folder not found", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if parent.ParentID.Valid {
+		return "Folders can only be nested one level deep", nil
+	}
+	return "", nil
+}
+
+func (apiCfg *apiConfig) handlerCreateFolder(w http.ResponseWriter, r *http.Request, user database.User) {
+	decoder := json.NewDecoder(r.Body)
+
+	params := folderParameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Failed to decode request body: %v", err))
+		return
+	}
+
+	msg, err := apiCfg.validateFolderParams(r.Context(), user, &params)
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Couldn't validate folder: %v", err))
+		return
+	}
+	if msg != "" {
+		respondWithError(w, 400, msg)
+		return
+	}
+
+	folder, err := apiCfg.DB.CreateFolder(r.Context(), database.CreateFolderParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+		UserID:    user.ID,
+		ParentID:  nullUUID(params.ParentID),
+		Name:      params.Name,
+	})
+	if isUniqueViolation(err) {
+		respondWithError(w, 409, "A folder with this name already exists")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to create folder: %v", err))
+		return
+	}
+
+	respondWithJSON(w, 201, databaseFolderToFolder(folder))
+}
+
+func (apiCfg *apiConfig) handlerGetFolders(w http.ResponseWriter, r *http.Request, user database.User) {
+	folders, err := apiCfg.DB.GetFolders(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Couldn't get folders: %v", err))
+		return
+	}
+
+	respondWithJSON(w, 200, databaseFoldersToFolders(folders))
+}
+
+func (apiCfg *apiConfig) handlerUpdateFolder(w http.ResponseWriter, r *http.Request, user database.User) {
+	folderID, err := uuid.Parse(chi.URLParam(r, "folderID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Failed to parse folder ID: %v", err))
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := folderParameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Failed to decode request body: %v", err))
+		return
+	}
+
+	if params.ParentID != nil {
+		if *params.ParentID == folderID {
+			respondWithError(w, 400, "A folder can't be its own parent")
+			return
+		}
+		children, err := apiCfg.DB.CountChildFolders(r.Context(), uuid.NullUUID{UUID: folderID, Valid: true})
+		if err != nil {
+			respondWithError(w, 500, fmt.Sprintf("Couldn't count child folders: %v", err))
+			return
+		}
+		if children > 0 {
+			respondWithError(w, 400, "Folders can only be nested one level deep")
+			return
+		}
+	}
+
+	msg, err := apiCfg.validateFolderParams(r.Context(), user, &params)
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Couldn't validate folder: %v", err))
+		return
+	}
+	if msg != "" {
+		respondWithError(w, 400, msg)
+		return
+	}
+
+	folder, err := apiCfg.DB.UpdateFolder(r.Context(), database.UpdateFolderParams{
+		ID:       folderID,
+		UserID:   user.ID,
+		Name:     params.Name,
+		ParentID: nullUUID(params.ParentID),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 404, "Folder not found")
+		return
+	}
+	if isUniqueViolation(err) {
+		respondWithError(w, 409, "A folder with this name already exists")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to update folder: %v", err))
+		return
+	}
+
+	respondWithJSON(w, 200, databaseFolderToFolder(folder))
+}
+
+// handlerDeleteFolder deletes a folder and its subfolders. The feed follows
+// inside them are kept and simply end up outside any folder.
+func (apiCfg *apiConfig) handlerDeleteFolder(w http.ResponseWriter, r *http.Request, user database.User) {
+	folderID, err := uuid.Parse(chi.URLParam(r, "folderID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Failed to parse folder ID: %v", err))
+		return
+	}
+
+	deleted, err := apiCfg.DB.DeleteFolder(r.Context(), database.DeleteFolderParams{
+		ID:     folderID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to delete folder: %v", err))
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, 404, "Folder not found")
+		return
+	}
+
+	respondWithJSON(w, 200, map[string]string{
+		"message": "Folder deleted successfully",
+	})
+}
+
+func (apiCfg *apiConfig) handlerSetFeedFollowFolder(w http.ResponseWriter, r *http.Request, user database.User) {
+	type parameters struct {
+		FolderID *uuid.UUID `json:"folder_id"`
+	}
+
+	feedFollowID, err := uuid.Parse(chi.URLParam(r, "feedFollowID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Failed to parse feed follow ID: %v", err))
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Failed to decode request body: %v", err))
+		return
+	}
+
+	if params.FolderID != nil {
+		_, err := apiCfg.DB.GetFolderByID(r.Context(), database.GetFolderByIDParams{
+			ID:     *params.FolderID,
+			UserID: user.ID,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, 404, "Folder not found")
+			return
+		}
+		if err != nil {
+			respondWithError(w, 500, fmt.Sprintf("Couldn't get folder: %v", err))
+			return
+		}
+	}
+
+	feedFollow, err := apiCfg.DB.SetFeedFollowFolder(r.Context(), database.SetFeedFollowFolderParams{
+		ID:       feedFollowID,
+		UserID:   user.ID,
+		FolderID: nullUUID(params.FolderID),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 404, "Feed follow not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to move feed follow: %v", err))
+		return
+	}
+
+	respondWithJSON(w, 200, databaseFeedFollowToFeedFollow(feedFollow))
+}
+
+func nullUUID(id *uuid.UUID) uuid.NullUUID {
+	if id == nil {
+		return uuid.NullUUID{}
+	}
+	return uuid.NullUUID{UUID: *id, Valid: true}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/Jayant-Verma/rssagg/internal/database"
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+)
+
+// readOPMLUpload accepts the file either as the "file" field of a multipart
+// form or as the raw request body.
+func readOPMLUpload(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxOPMLSize)
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		return io.ReadAll(r.Body)
+	}
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(file)
+}
+
+func (apiCfg *apiConfig) handlerImportOPML(w http.ResponseWriter, r *http.Request, user database.User) {
+	dat, err := readOPMLUpload(w, r)
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		respondWithError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("File must be at most %d MB", maxOPMLSize>>20))
+		return
+	}
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Failed to read OPML file: %v", err))
+		return
+	}
+
+	entries, err := parseOPML(dat)
+	if err != nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
+	if len(entries) > maxOPMLEntries {
+		respondWithError(w, 400, fmt.Sprintf("File must contain at most %d feeds", maxOPMLEntries))
+		return
+	}
+
+	tx, err := apiCfg.Conn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to start transaction: %v", err))
+		return
+	}
+	defer tx.Rollback()
+	qtx := apiCfg.DB.WithTx(tx)
+
+	opmlImport, err := qtx.CreateOPMLImport(r.Context(), database.CreateOPMLImportParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+		UserID:    user.ID,
+		Total:     int32(len(entries)),
+	})
+	if isUniqueViolation(err) {
+		respondWithError(w, 409, "Another import is still in progress")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to create import: %v", err))
+		return
+	}
+
+	for i, entry := range entries {
+		err = qtx.CreateOPMLImportItem(r.Context(), database.CreateOPMLImportItemParams{
+			ID:           uuid.New(),
+			ImportID:     opmlImport.ID,
+			Position:     int32(i),
+			Title:        entry.Title,
+			Url:          entry.URL,
+			ParentFolder: sql.NullString{String: entry.ParentFolder, Valid: entry.ParentFolder != ""},
+			Folder:       sql.NullString{String: entry.Folder, Valid: entry.Folder != ""},
+		})
+		if err != nil {
+			respondWithError(w, 500, fmt.Sprintf("Failed to create import: %v", err))
+			return
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to commit transaction: %v", err))
+		return
+	}
+
+	items, err := apiCfg.DB.GetOPMLImportItems(r.Context(), opmlImport.ID)
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Couldn't get import items: %v", err))
+		return
+	}
+
+	// Fetching hundreds of feeds takes a while, so the import runs in the
+	// background and the client polls the import for progress.
+	go apiCfg.runOPMLImport(opmlImport.ID, user)
+
+	w.Header().Set("Location", fmt.Sprintf("/v1/opml/imports/%s", opmlImport.ID))
+	respondWithJSON(w, http.StatusAccepted, databaseOPMLImportToOPMLImport(opmlImport, items))
+}
+
+func (apiCfg *apiConfig) handlerGetOPMLImport(w http.ResponseWriter, r *http.Request, user database.User) {
+	importID, err := uuid.Parse(chi.URLParam(r, "importID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Failed to parse import ID: %v", err))
+		return
+	}
+
+	opmlImport, err := apiCfg.DB.GetOPMLImport(r.Context(), database.GetOPMLImportParams{
+		ID:     importID,
+		UserID: user.ID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 404, "Import not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Couldn't get import: %v", err))
+		return
+	}
+
+	items, err := apiCfg.DB.GetOPMLImportItems(r.Context(), opmlImport.ID)
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Couldn't get import items: %v", err))
+		return
+	}
+
+	respondWithJSON(w, 200, databaseOPMLImportToOPMLImport(opmlImport, items))
+}
+
+func (apiCfg *apiConfig) handlerExportOPML(w http.ResponseWriter, r *http.Request, user database.User) {
+	folders, err := apiCfg.DB.GetFolders(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Couldn't get folders: %v", err))
+		return
+	}
+
+	follows, err := apiCfg.DB.GetFeedFollowsForExport(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Couldn't get feed follows: %v", err))
+		return
+	}
+
+	dat, err := xml.MarshalIndent(buildOPML(user, folders, follows, time.Now()), "", "  ")
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to build OPML: %v", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/x-opml; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="subscriptions.opml"`)
+	w.WriteHeader(200)
+	w.Write([]byte(xml.Header))
+	w.Write(dat)
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Jayant-Verma/rssagg/internal/database"
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+)
+
+// outputFeedSize is how many of the latest posts an output feed carries.
+const outputFeedSize = 50
+
+func parseOutputFormat(r *http.Request) (string, bool) {
+	switch format := r.URL.Query().Get("format"); format {
+	case "", feedFormatRSS:
+		return feedFormatRSS, true
+	case feedFormatAtom, feedFormatJSON:
+		return format, true
+	default:
+		return "", false
+	}
+}
+
+func (apiCfg *apiConfig) handlerOutputTimeline(w http.ResponseWriter, r *http.Request, user database.User) {
+	apiCfg.respondWithPostsFeed(w, r, user, fmt.Sprintf("%s's timeline", user.Name), database.GetPostsForUserParams{})
+}
+
+func (apiCfg *apiConfig) handlerOutputFolder(w http.ResponseWriter, r *http.Request, user database.User) {
+	folderID, err := uuid.Parse(chi.URLParam(r, "folderID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Failed to parse folder ID: %v", err))
+		return
+	}
+
+	folder, err := apiCfg.DB.GetFolderByID(r.Context(), database.GetFolderByIDParams{
+		ID:     folderID,
+		UserID: user.ID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 404, "Folder not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Couldn't get folder: %v", err))
+		return
+	}
+
+	apiCfg.respondWithPostsFeed(w, r, user, fmt.Sprintf("%s: %s", user.Name, folder.Name), database.GetPostsForUserParams{
+		FolderID: uuid.NullUUID{UUID: folder.ID, Valid: true},
+	})
+}
+
+func (apiCfg *apiConfig) handlerOutputTag(w http.ResponseWriter, r *http.Request, user database.User) {
+	tag, err := url.PathUnescape(chi.URLParam(r, "tag"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Failed to parse tag: %v", err))
+		return
+	}
+	tag, msg := cleanTagName(tag)
+	if msg != "" {
+		respondWithError(w, 400, msg)
+		return
+	}
+
+	apiCfg.respondWithPostsFeed(w, r, user, fmt.Sprintf("%s: #%s", user.Name, tag), database.GetPostsForUserParams{
+		Tag: sql.NullString{String: tag, Valid: true},
+	})
+}
+
+// respondWithPostsFeed renders the latest posts GetPostsForUser returns for
+// params, with the user, limit and ordering filled in.
+func (apiCfg *apiConfig) respondWithPostsFeed(w http.ResponseWriter, r *http.Request, user database.User, title string, params database.GetPostsForUserParams) {
+	format, ok := parseOutputFormat(r)
+	if !ok {
+		respondWithError(w, 400, "format must be rss, atom or json")
+		return
+	}
+
+	params.UserID = user.ID
+	params.Limit = outputFeedSize
+	rows, err := apiCfg.DB.GetPostsForUser(r.Context(), params)
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Couldn't get posts: %v", err))
+		return
+	}
+
+	respondWithOutputFeed(w, r, format, apiCfg.newOutputFeed(r, user, title, databasePostRowsToPosts(rows)))
+}
+
+func (apiCfg *apiConfig) handlerOutputStarred(w http.ResponseWriter, r *http.Request, user database.User) {
+	format, ok := parseOutputFormat(r)
+	if !ok {
+		respondWithError(w, 400, "format must be rss, atom or json")
+		return
+	}
+
+	rows, err := apiCfg.DB.GetStarredPostsForUser(r.Context(), database.GetStarredPostsForUserParams{
+		UserID: user.ID,
+		Limit:  outputFeedSize,
+	})
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Couldn't get starred posts: %v", err))
+		return
+	}
+
+	title := fmt.Sprintf("%s's starred posts", user.Name)
+	respondWithOutputFeed(w, r, format, apiCfg.newOutputFeed(r, user, title, databaseStarredPostRowsToPosts(rows)))
+}
+
+// newOutputFeed dates the feed by its most recent change: a post being
+// added, updated or starred. An empty feed is dated by the user's sign up.
+func (apiCfg *apiConfig) newOutputFeed(r *http.Request, user database.User, title string, posts []Post) outputFeed {
+	feed := outputFeed{
+		Title:   title,
+		SelfURL: apiCfg.requestURL(r),
+		Updated: user.CreatedAt,
+		Posts:   posts,
+	}
+	for _, post := range posts {
+		for _, t := range []time.Time{post.CreatedAt, post.UpdatedAt} {
+			if t.After(feed.Updated) {
+				feed.Updated = t
+			}
+		}
+		if post.StarredAt != nil && post.StarredAt.After(feed.Updated) {
+			feed.Updated = *post.StarredAt
+		}
+	}
+	return feed
+}
+
+// requestURL reconstructs the URL the client requested, for the feed's link
+// to itself. X-Forwarded-Proto is only believed when one of our own proxies
+// sent the request.
+func (apiCfg *apiConfig) requestURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if apiCfg.isTrustedProxy(net.ParseIP(host)) {
+		if proto := r.Header.Get("X-Forwarded-Proto"); proto == "http" || proto == "https" {
+			scheme = proto
+		}
+	}
+	return fmt.Sprintf("%s://%s%s", scheme, r.Host, r.URL.RequestURI())
+}
+
+// respondWithOutputFeed writes the feed with validators derived from its
+// content, answering conditional requests from feed readers with 304.
+func respondWithOutputFeed(w http.ResponseWriter, r *http.Request, format string, feed outputFeed) {
+	dat, err := feed.render(format)
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to render feed: %v", err))
+		return
+	}
+
+	sum := sha256.Sum256(dat)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	lastModified := feed.Updated.UTC().Truncate(time.Second)
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Last-Modified", lastModified.Format(http.TimeFormat))
+	// The URL carries the user's secret token, so shared caches must not
+	// keep a copy.
+	w.Header().Set("Cache-Control", "private, max-age=300")
+
+	if notModified(r, etag, lastModified) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", outputFeedContentTypes[format])
+	w.WriteHeader(200)
+	w.Write(dat)
+}
+
+// notModified evaluates If-None-Match, falling back to If-Modified-Since only
+// when the client sent no ETag, as RFC 9110 requires.
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, candidate := range strings.Split(inm, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == etag || candidate == "*" {
+				return true
+			}
+		}
+		return false
+	}
+
+	ims, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	return !lastModified.After(ims)
+}
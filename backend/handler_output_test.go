@@ -0,0 +1,51 @@
+package main
+
+import (
+	"net"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequestURL(t *testing.T) {
+	_, proxies, _ := net.ParseCIDR("10.0.0.0/8")
+	apiCfg := &apiConfig{TrustedProxies: []*net.IPNet{proxies}}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		proto      string
+		want       string
+	}{
+		{
+			name:       "plain request",
+			remoteAddr: "203.0.113.7:4321",
+			want:       "http://feeds.example.com/v1/output/token/timeline?format=atom",
+		},
+		{
+			name:       "forwarded proto from an untrusted client",
+			remoteAddr: "203.0.113.7:4321",
+			proto:      "https",
+			want:       "http://feeds.example.com/v1/output/token/timeline?format=atom",
+		},
+		{
+			name:       "forwarded proto from a trusted proxy",
+			remoteAddr: "10.0.0.2:4321",
+			proto:      "https",
+			want:       "https://feeds.example.com/v1/output/token/timeline?format=atom",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "http://feeds.example.com/v1/output/token/timeline?format=atom", nil)
+			r.RemoteAddr = tt.remoteAddr
+			if tt.proto != "" {
+				r.Header.Set("X-Forwarded-Proto", tt.proto)
+			}
+
+			if got := apiCfg.requestURL(r); got != tt.want {
+				t.Errorf("requestURL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
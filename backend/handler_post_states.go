@@ -0,0 +1,170 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Jayant-Verma/rssagg/internal/database"
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+)
+
+func (apiCfg *apiConfig) handlerMarkPostRead(w http.ResponseWriter, r *http.Request, user database.User) {
+	apiCfg.setPostRead(w, r, user, true)
+}
+
+func (apiCfg *apiConfig) handlerMarkPostUnread(w http.ResponseWriter, r *http.Request, user database.User) {
+	apiCfg.setPostRead(w, r, user, false)
+}
+
+func (apiCfg *apiConfig) setPostRead(w http.ResponseWriter, r *http.Request, user database.User, read bool) {
+	postID, err := uuid.Parse(chi.URLParam(r, "postID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Failed to parse post ID: %v", err))
+		return
+	}
+
+	updated, err := apiCfg.DB.SetPostRead(r.Context(), database.SetPostReadParams{
+		Read:   read,
+		PostID: postID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to update post: %v", err))
+		return
+	}
+	if updated == 0 {
+		respondWithError(w, 404, "Post not found")
+		return
+	}
+
+	respondWithJSON(w, 200, map[string]bool{"read": read})
+}
+
+func (apiCfg *apiConfig) handlerStarPost(w http.ResponseWriter, r *http.Request, user database.User) {
+	postID, err := uuid.Parse(chi.URLParam(r, "postID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Failed to parse post ID: %v", err))
+		return
+	}
+
+	updated, err := apiCfg.DB.StarPost(r.Context(), database.StarPostParams{
+		ID:     postID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to star post: %v", err))
+		return
+	}
+	if updated == 0 {
+		respondWithError(w, 404, "Post not found")
+		return
+	}
+
+	respondWithJSON(w, 200, map[string]bool{"starred": true})
+}
+
+// handlerUnstarPost works on any post the user starred, even when they have
+// since unfollowed its feed.
+func (apiCfg *apiConfig) handlerUnstarPost(w http.ResponseWriter, r *http.Request, user database.User) {
+	postID, err := uuid.Parse(chi.URLParam(r, "postID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Failed to parse post ID: %v", err))
+		return
+	}
+
+	_, err = apiCfg.DB.UnstarPost(r.Context(), database.UnstarPostParams{
+		PostID: postID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to unstar post: %v", err))
+		return
+	}
+
+	respondWithJSON(w, 200, map[string]bool{"starred": false})
+}
+
+// handlerMarkPostsRead marks many posts as read at once. Either a list of
+// post IDs is given, or any combination of feed, folder and cutoff time;
+// "all" has to be set explicitly to mark everything as read.
+func (apiCfg *apiConfig) handlerMarkPostsRead(w http.ResponseWriter, r *http.Request, user database.User) {
+	type parameters struct {
+		PostIDs  []uuid.UUID `json:"post_ids"`
+		FeedID   *uuid.UUID  `json:"feed_id"`
+		FolderID *uuid.UUID  `json:"folder_id"`
+		Before   *time.Time  `json:"before"`
+		All      bool        `json:"all"`
+	}
+	decoder := json.NewDecoder(r.Body)
+
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Failed to decode request body: %v", err))
+		return
+	}
+
+	if len(params.PostIDs) > 0 {
+		if params.FeedID != nil || params.FolderID != nil || params.Before != nil || params.All {
+			respondWithError(w, 400, "post_ids can't be combined with other filters")
+			return
+		}
+
+		tx, err := apiCfg.Conn.BeginTx(r.Context(), nil)
+		if err != nil {
+			respondWithError(w, 500, fmt.Sprintf("Failed to mark posts as read: %v", err))
+			return
+		}
+		defer tx.Rollback()
+		qtx := apiCfg.DB.WithTx(tx)
+
+		var marked int64
+		for _, postID := range params.PostIDs {
+			updated, err := qtx.SetPostRead(r.Context(), database.SetPostReadParams{
+				Read:   true,
+				PostID: postID,
+				UserID: user.ID,
+			})
+			if err != nil {
+				respondWithError(w, 500, fmt.Sprintf("Failed to mark posts as read: %v", err))
+				return
+			}
+			marked += updated
+		}
+
+		if err := tx.Commit(); err != nil {
+			respondWithError(w, 500, fmt.Sprintf("Failed to mark posts as read: %v", err))
+			return
+		}
+
+		respondWithJSON(w, 200, map[string]int64{"marked": marked})
+		return
+	}
+
+	if params.FeedID == nil && params.FolderID == nil && params.Before == nil && !params.All {
+		respondWithError(w, 400, "One of post_ids, feed_id, folder_id, before or all is required")
+		return
+	}
+
+	before := sql.NullTime{}
+	if params.Before != nil {
+		before = sql.NullTime{Time: params.Before.UTC(), Valid: true}
+	}
+
+	marked, err := apiCfg.DB.MarkPostsRead(r.Context(), database.MarkPostsReadParams{
+		UserID:   user.ID,
+		FeedID:   nullUUID(params.FeedID),
+		FolderID: nullUUID(params.FolderID),
+		Before:   before,
+	})
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to mark posts as read: %v", err))
+		return
+	}
+
+	respondWithJSON(w, 200, map[string]int64{"marked": marked})
+}
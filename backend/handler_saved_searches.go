@@ -0,0 +1,269 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Jayant-Verma/rssagg/internal/database"
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+)
+
+const (
+	maxSavedSearchNameLength = 255
+	maxSearchLangLength      = 35
+)
+
+type savedSearchParameters struct {
+	Name  string `json:"name"`
+	Query string `json:"query"`
+	Lang  string `json:"lang"`
+}
+
+// validate trims the parameters and returns a client-facing message when
+// they are invalid.
+func (params *savedSearchParameters) validate() string {
+	params.Name = strings.TrimSpace(params.Name)
+	params.Query = strings.TrimSpace(params.Query)
+	params.Lang = strings.TrimSpace(params.Lang)
+
+	if params.Name == "" || utf8.RuneCountInString(params.Name) > maxSavedSearchNameLength {
+		return fmt.Sprintf("Name must be between 1 and %d characters", maxSavedSearchNameLength)
+	}
+	if params.Query == "" || utf8.RuneCountInString(params.Query) > maxSearchQueryLength {
+		return fmt.Sprintf("Query must be between 1 and %d characters", maxSearchQueryLength)
+	}
+	if len(params.Lang) > maxSearchLangLength {
+		return fmt.Sprintf("Lang must be at most %d characters", maxSearchLangLength)
+	}
+	return ""
+}
+
+// handlerCreateSavedSearch saves a search and matches it against the posts
+// already stored; new posts are matched by the scraper as they arrive.
+func (apiCfg *apiConfig) handlerCreateSavedSearch(w http.ResponseWriter, r *http.Request, user database.User) {
+	decoder := json.NewDecoder(r.Body)
+
+	params := savedSearchParameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Failed to decode request body: %v", err))
+		return
+	}
+	if msg := params.validate(); msg != "" {
+		respondWithError(w, 400, msg)
+		return
+	}
+
+	tx, err := apiCfg.Conn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to create saved search: %v", err))
+		return
+	}
+	defer tx.Rollback()
+	qtx := apiCfg.DB.WithTx(tx)
+
+	savedSearch, err := qtx.CreateSavedSearch(r.Context(), database.CreateSavedSearchParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+		UserID:    user.ID,
+		Name:      params.Name,
+		Query:     params.Query,
+		Lang:      params.Lang,
+	})
+	if isUniqueViolation(err) {
+		respondWithError(w, 409, "A saved search with this name already exists")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to create saved search: %v", err))
+		return
+	}
+
+	_, err = qtx.BackfillSavedSearch(r.Context(), savedSearch.ID)
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to match saved search: %v", err))
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to create saved search: %v", err))
+		return
+	}
+
+	respondWithJSON(w, 201, databaseSavedSearchToSavedSearch(savedSearch))
+}
+
+func (apiCfg *apiConfig) handlerGetSavedSearches(w http.ResponseWriter, r *http.Request, user database.User) {
+	savedSearches, err := apiCfg.DB.GetSavedSearches(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Couldn't get saved searches: %v", err))
+		return
+	}
+
+	respondWithJSON(w, 200, databaseSavedSearchesToSavedSearches(savedSearches))
+}
+
+// handlerUpdateSavedSearch renames a saved search or changes its query. A
+// new query or language replaces all earlier matches.
+func (apiCfg *apiConfig) handlerUpdateSavedSearch(w http.ResponseWriter, r *http.Request, user database.User) {
+	savedSearchID, err := uuid.Parse(chi.URLParam(r, "savedSearchID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Failed to parse saved search ID: %v", err))
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := savedSearchParameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Failed to decode request body: %v", err))
+		return
+	}
+	if msg := params.validate(); msg != "" {
+		respondWithError(w, 400, msg)
+		return
+	}
+
+	tx, err := apiCfg.Conn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to update saved search: %v", err))
+		return
+	}
+	defer tx.Rollback()
+	qtx := apiCfg.DB.WithTx(tx)
+
+	existing, err := qtx.GetSavedSearchByID(r.Context(), database.GetSavedSearchByIDParams{
+		ID:     savedSearchID,
+		UserID: user.ID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 404, "Saved search not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Couldn't get saved search: %v", err))
+		return
+	}
+
+	savedSearch, err := qtx.UpdateSavedSearch(r.Context(), database.UpdateSavedSearchParams{
+		ID:     savedSearchID,
+		UserID: user.ID,
+		Name:   params.Name,
+		Query:  params.Query,
+		Lang:   params.Lang,
+	})
+	if isUniqueViolation(err) {
+		respondWithError(w, 409, "A saved search with this name already exists")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to update saved search: %v", err))
+		return
+	}
+
+	if existing.Query != savedSearch.Query || existing.Lang != savedSearch.Lang {
+		err = rematchSavedSearch(r.Context(), qtx, savedSearch.ID)
+		if err != nil {
+			respondWithError(w, 500, fmt.Sprintf("Failed to match saved search: %v", err))
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to update saved search: %v", err))
+		return
+	}
+
+	respondWithJSON(w, 200, databaseSavedSearchToSavedSearch(savedSearch))
+}
+
+func rematchSavedSearch(ctx context.Context, qtx *database.Queries, savedSearchID uuid.UUID) error {
+	err := qtx.ClearSavedSearchMatches(ctx, savedSearchID)
+	if err != nil {
+		return err
+	}
+	_, err = qtx.BackfillSavedSearch(ctx, savedSearchID)
+	return err
+}
+
+func (apiCfg *apiConfig) handlerDeleteSavedSearch(w http.ResponseWriter, r *http.Request, user database.User) {
+	savedSearchID, err := uuid.Parse(chi.URLParam(r, "savedSearchID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Failed to parse saved search ID: %v", err))
+		return
+	}
+
+	deleted, err := apiCfg.DB.DeleteSavedSearch(r.Context(), database.DeleteSavedSearchParams{
+		ID:     savedSearchID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to delete saved search: %v", err))
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, 404, "Saved search not found")
+		return
+	}
+
+	respondWithJSON(w, 200, map[string]string{
+		"message": "Saved search deleted successfully",
+	})
+}
+
+// unsupportedSavedSearchFilters are GET /v1/posts parameters the timeline
+// of a saved search does not accept.
+var unsupportedSavedSearchFilters = []string{"feed_id", "folder_id", "tag", "since", "until", "starred"}
+
+// handlerGetSavedSearchPosts is the timeline of a saved search. It pages
+// like GET /v1/posts and accepts its limit, before, after, order and read
+// parameters.
+func (apiCfg *apiConfig) handlerGetSavedSearchPosts(w http.ResponseWriter, r *http.Request, user database.User) {
+	savedSearchID, err := uuid.Parse(chi.URLParam(r, "savedSearchID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Failed to parse saved search ID: %v", err))
+		return
+	}
+
+	filters, err := parsePostFilters(r.URL.Query())
+	if err != nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
+	for _, key := range unsupportedSavedSearchFilters {
+		if r.URL.Query().Has(key) {
+			respondWithError(w, 400, fmt.Sprintf("%s is not supported for saved searches", key))
+			return
+		}
+	}
+
+	_, err = apiCfg.DB.GetSavedSearchByID(r.Context(), database.GetSavedSearchByIDParams{
+		ID:     savedSearchID,
+		UserID: user.ID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 404, "Saved search not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Couldn't get saved search: %v", err))
+		return
+	}
+
+	posts, err := apiCfg.getSavedSearchPostsPage(r.Context(), user, savedSearchID, filters, int32(filters.Limit+1))
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Couldn't get posts: %v", err))
+		return
+	}
+
+	respondWithJSON(w, 200, newPostsPage(posts, filters.Limit))
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Jayant-Verma/rssagg/internal/database"
+)
+
+const (
+	defaultSearchPageSize = 20
+	maxSearchPageSize     = 100
+	maxSearchQueryLength  = 256
+	// Ranking has to score every match before the offset, so deep pages
+	// are refused; narrow the query instead.
+	maxSearchOffset = 1000
+)
+
+// handlerSearchPosts runs a full-text search over the posts of the feeds the
+// user follows. q uses web search syntax ("quoted phrases", -excluded, or).
+// lang picks the stemming language for the query; without it only exact
+// words match.
+func (apiCfg *apiConfig) handlerSearchPosts(w http.ResponseWriter, r *http.Request, user database.User) {
+	query := r.URL.Query()
+
+	q := strings.TrimSpace(query.Get("q"))
+	if q == "" {
+		respondWithError(w, 400, "q is required")
+		return
+	}
+	if utf8.RuneCountInString(q) > maxSearchQueryLength {
+		respondWithError(w, 400, fmt.Sprintf("q must be at most %d characters", maxSearchQueryLength))
+		return
+	}
+
+	limit := defaultSearchPageSize
+	if raw := query.Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxSearchPageSize {
+			respondWithError(w, 400, fmt.Sprintf("limit must be between 1 and %d", maxSearchPageSize))
+			return
+		}
+		limit = n
+	}
+	offset := 0
+	if raw := query.Get("offset"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 || n > maxSearchOffset {
+			respondWithError(w, 400, fmt.Sprintf("offset must be between 0 and %d", maxSearchOffset))
+			return
+		}
+		offset = n
+	}
+
+	feedID, err := parseUUIDParam(query, "feed_id")
+	if err != nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
+	folderID, err := parseUUIDParam(query, "folder_id")
+	if err != nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
+
+	// One extra row tells us whether there is another page.
+	rows, err := apiCfg.DB.SearchPostsForUser(r.Context(), database.SearchPostsForUserParams{
+		UserID:   user.ID,
+		Query:    q,
+		Lang:     query.Get("lang"),
+		FeedID:   feedID,
+		FolderID: folderID,
+		Limit:    int32(limit + 1),
+		Offset:   int32(offset),
+	})
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Couldn't search posts: %v", err))
+		return
+	}
+
+	page := PostSearchPage{Results: databaseSearchRowsToResults(rows)}
+	if len(page.Results) > limit {
+		page.Results = page.Results[:limit]
+		if next := offset + limit; next <= maxSearchOffset {
+			page.NextOffset = &next
+		}
+	}
+
+	respondWithJSON(w, 200, page)
+}
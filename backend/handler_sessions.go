@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/Jayant-Verma/rssagg/internal/database"
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+)
+
+// handlerGetSessions lists the devices the user is logged in on, marking the
+// one making the request.
+func (apiCfg *apiConfig) handlerGetSessions(w http.ResponseWriter, r *http.Request, user database.User) {
+	sessions, err := apiCfg.DB.GetActiveSessionsForUser(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Couldn't get sessions: %v", err))
+		return
+	}
+
+	info, _ := authFromContext(r.Context())
+	respondWithJSON(w, 200, databaseSessionsToSessions(sessions, info.SessionID))
+}
+
+func (apiCfg *apiConfig) handlerRevokeSession(w http.ResponseWriter, r *http.Request, user database.User) {
+	sessionID, err := uuid.Parse(chi.URLParam(r, "sessionID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Failed to parse session ID: %v", err))
+		return
+	}
+
+	revoked, err := apiCfg.DB.RevokeSessionForUser(r.Context(), database.RevokeSessionForUserParams{
+		ID:     sessionID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to revoke session: %v", err))
+		return
+	}
+	if revoked == 0 {
+		respondWithError(w, 404, "Session not found")
+		return
+	}
+
+	respondWithJSON(w, 200, map[string]string{
+		"message": "Session revoked successfully",
+	})
+}
+
+// handlerRevokeSessions logs the user out everywhere. With
+// ?keep_current=true the session making the request stays logged in.
+func (apiCfg *apiConfig) handlerRevokeSessions(w http.ResponseWriter, r *http.Request, user database.User) {
+	// uuid.Nil matches no session, so every session is revoked.
+	keep := uuid.Nil
+	if r.URL.Query().Get("keep_current") == "true" {
+		info, _ := authFromContext(r.Context())
+		keep = info.SessionID.UUID
+	}
+
+	revoked, err := apiCfg.DB.RevokeSessionsForUser(r.Context(), database.RevokeSessionsForUserParams{
+		UserID: user.ID,
+		ID:     keep,
+	})
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to revoke sessions: %v", err))
+		return
+	}
+
+	respondWithJSON(w, 200, map[string]int64{
+		"revoked": revoked,
+	})
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Jayant-Verma/rssagg/internal/database"
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+)
+
+const maxTagNameLength = 64
+
+// cleanTagName trims name and returns a client-facing message when it isn't
+// a valid tag name.
+func cleanTagName(name string) (string, string) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxTagNameLength {
+		return "", fmt.Sprintf("Tag must be between 1 and %d characters", maxTagNameLength)
+	}
+	return name, ""
+}
+
+func (apiCfg *apiConfig) handlerGetTags(w http.ResponseWriter, r *http.Request, user database.User) {
+	tags, err := apiCfg.DB.GetTagsWithCounts(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Couldn't get tags: %v", err))
+		return
+	}
+
+	respondWithJSON(w, 200, databaseTagRowsToTagsWithCounts(tags))
+}
+
+func (apiCfg *apiConfig) handlerRenameTag(w http.ResponseWriter, r *http.Request, user database.User) {
+	type parameters struct {
+		Name string `json:"name"`
+	}
+
+	tagID, err := uuid.Parse(chi.URLParam(r, "tagID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Failed to parse tag ID: %v", err))
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Failed to decode request body: %v", err))
+		return
+	}
+
+	name, msg := cleanTagName(params.Name)
+	if msg != "" {
+		respondWithError(w, 400, msg)
+		return
+	}
+
+	tag, err := apiCfg.DB.RenameTag(r.Context(), database.RenameTagParams{
+		ID:     tagID,
+		UserID: user.ID,
+		Name:   name,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 404, "Tag not found")
+		return
+	}
+	if isUniqueViolation(err) {
+		respondWithError(w, 409, "A tag with this name already exists")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to rename tag: %v", err))
+		return
+	}
+
+	respondWithJSON(w, 200, databaseTagToTag(tag))
+}
+
+// handlerDeleteTag deletes a tag and removes it from every post.
+func (apiCfg *apiConfig) handlerDeleteTag(w http.ResponseWriter, r *http.Request, user database.User) {
+	tagID, err := uuid.Parse(chi.URLParam(r, "tagID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Failed to parse tag ID: %v", err))
+		return
+	}
+
+	deleted, err := apiCfg.DB.DeleteTag(r.Context(), database.DeleteTagParams{
+		ID:     tagID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to delete tag: %v", err))
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, 404, "Tag not found")
+		return
+	}
+
+	respondWithJSON(w, 200, map[string]string{
+		"message": "Tag deleted successfully",
+	})
+}
+
+func (apiCfg *apiConfig) handlerGetPostTags(w http.ResponseWriter, r *http.Request, user database.User) {
+	postID, err := uuid.Parse(chi.URLParam(r, "postID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Failed to parse post ID: %v", err))
+		return
+	}
+
+	tags, err := apiCfg.DB.GetTagsForPost(r.Context(), database.GetTagsForPostParams{
+		UserID: user.ID,
+		PostID: postID,
+	})
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Couldn't get tags: %v", err))
+		return
+	}
+
+	respondWithJSON(w, 200, databaseTagsToTags(tags))
+}
+
+// handlerAddPostTag tags a post by name, creating the tag the first time
+// the name is used. It responds with all of the post's tags.
+func (apiCfg *apiConfig) handlerAddPostTag(w http.ResponseWriter, r *http.Request, user database.User) {
+	type parameters struct {
+		Name string `json:"name"`
+	}
+
+	postID, err := uuid.Parse(chi.URLParam(r, "postID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Failed to parse post ID: %v", err))
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Failed to decode request body: %v", err))
+		return
+	}
+
+	name, msg := cleanTagName(params.Name)
+	if msg != "" {
+		respondWithError(w, 400, msg)
+		return
+	}
+
+	_, err = apiCfg.DB.GetPostForUser(r.Context(), database.GetPostForUserParams{
+		ID:     postID,
+		UserID: user.ID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 404, "Post not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Couldn't get post: %v", err))
+		return
+	}
+
+	tx, err := apiCfg.Conn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to tag post: %v", err))
+		return
+	}
+	defer tx.Rollback()
+	qtx := apiCfg.DB.WithTx(tx)
+
+	tag, err := qtx.UpsertTag(r.Context(), database.UpsertTagParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now().UTC(),
+		UserID:    user.ID,
+		Name:      name,
+	})
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to create tag: %v", err))
+		return
+	}
+
+	err = qtx.TagPost(r.Context(), database.TagPostParams{
+		TagID:  tag.ID,
+		PostID: postID,
+	})
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to tag post: %v", err))
+		return
+	}
+
+	tags, err := qtx.GetTagsForPost(r.Context(), database.GetTagsForPostParams{
+		UserID: user.ID,
+		PostID: postID,
+	})
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Couldn't get tags: %v", err))
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to tag post: %v", err))
+		return
+	}
+
+	respondWithJSON(w, 200, databaseTagsToTags(tags))
+}
+
+// handlerRemovePostTag takes a tag off a post. The tag itself is kept even
+// when no posts are left with it.
+func (apiCfg *apiConfig) handlerRemovePostTag(w http.ResponseWriter, r *http.Request, user database.User) {
+	postID, err := uuid.Parse(chi.URLParam(r, "postID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Failed to parse post ID: %v", err))
+		return
+	}
+	tagID, err := uuid.Parse(chi.URLParam(r, "tagID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Failed to parse tag ID: %v", err))
+		return
+	}
+
+	removed, err := apiCfg.DB.UntagPost(r.Context(), database.UntagPostParams{
+		TagID:  tagID,
+		PostID: postID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to untag post: %v", err))
+		return
+	}
+	if removed == 0 {
+		respondWithError(w, 404, "Tag not found on post")
+		return
+	}
+
+	respondWithJSON(w, 200, map[string]string{
+		"message": "Tag removed successfully",
+	})
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Jayant-Verma/rssagg/internal/auth"
+	"github.com/Jayant-Verma/rssagg/internal/database"
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+)
+
+const (
+	scopePostsRead     = "posts:read"
+	scopePostsWrite    = "posts:write"
+	scopeFeedsManage   = "feeds:manage"
+	scopeFollowsManage = "follows:manage"
+	// scopeAdmin is for administering feeds: it covers the feed endpoints
+	// and keeps the owner's admin rights there, such as changing other
+	// users' feeds.
+	scopeAdmin = "admin"
+)
+
+var tokenScopes = map[string]bool{
+	scopePostsRead:     true,
+	scopePostsWrite:    true,
+	scopeFeedsManage:   true,
+	scopeFollowsManage: true,
+	scopeAdmin:         true,
+}
+
+// impliedScopes lists the scopes a token also has because of another one.
+var impliedScopes = map[string][]string{
+	scopeAdmin: {scopeFeedsManage},
+}
+
+const (
+	maxTokenNameLength   = 255
+	maxTokenLifetimeDays = 365
+)
+
+func (apiCfg *apiConfig) handlerCreatePersonalAccessToken(w http.ResponseWriter, r *http.Request, user database.User) {
+	type parameters struct {
+		Name          string   `json:"name"`
+		Scopes        []string `json:"scopes"`
+		ExpiresInDays *int     `json:"expires_in_days"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Failed to decode request body: %v", err))
+		return
+	}
+
+	name := strings.TrimSpace(params.Name)
+	if name == "" || utf8.RuneCountInString(name) > maxTokenNameLength {
+		respondWithError(w, 400, fmt.Sprintf("Name must be between 1 and %d characters", maxTokenNameLength))
+		return
+	}
+
+	if len(params.Scopes) == 0 {
+		respondWithError(w, 400, "At least one scope is required")
+		return
+	}
+	scopes := []string{}
+	for _, scope := range params.Scopes {
+		if !tokenScopes[scope] {
+			respondWithError(w, 400, fmt.Sprintf("Unknown scope %q, must be one of posts:read, posts:write, feeds:manage, follows:manage or admin", scope))
+			return
+		}
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	if slices.Contains(scopes, scopeAdmin) && !user.IsAdmin {
+		respondWithError(w, 403, "Only admins can create tokens with the admin scope")
+		return
+	}
+
+	expiresAt := sql.NullTime{}
+	if params.ExpiresInDays != nil {
+		if *params.ExpiresInDays < 1 || *params.ExpiresInDays > maxTokenLifetimeDays {
+			respondWithError(w, 400, fmt.Sprintf("expires_in_days must be between 1 and %d", maxTokenLifetimeDays))
+			return
+		}
+		expiresAt = sql.NullTime{Time: time.Now().UTC().AddDate(0, 0, *params.ExpiresInDays), Valid: true}
+	}
+
+	secret, err := auth.GenerateToken()
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to generate token: %v", err))
+		return
+	}
+	tokenString := personalAccessTokenPrefix + secret
+
+	token, err := apiCfg.DB.CreatePersonalAccessToken(r.Context(), database.CreatePersonalAccessTokenParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now().UTC(),
+		UserID:    user.ID,
+		Name:      name,
+		TokenHash: auth.HashToken(tokenString),
+		Scopes:    strings.Join(scopes, " "),
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to create token: %v", err))
+		return
+	}
+
+	// The token itself is only ever shown in this response.
+	respondWithJSON(w, 201, CreatedPersonalAccessToken{
+		PersonalAccessToken: databasePersonalAccessTokenToPersonalAccessToken(token),
+		Token:               tokenString,
+	})
+}
+
+func (apiCfg *apiConfig) handlerGetPersonalAccessTokens(w http.ResponseWriter, r *http.Request, user database.User) {
+	tokens, err := apiCfg.DB.GetPersonalAccessTokens(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Couldn't get tokens: %v", err))
+		return
+	}
+
+	respondWithJSON(w, 200, databasePersonalAccessTokensToPersonalAccessTokens(tokens))
+}
+
+func (apiCfg *apiConfig) handlerRevokePersonalAccessToken(w http.ResponseWriter, r *http.Request, user database.User) {
+	tokenID, err := uuid.Parse(chi.URLParam(r, "tokenID"))
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Failed to parse token ID: %v", err))
+		return
+	}
+
+	revoked, err := apiCfg.DB.RevokePersonalAccessToken(r.Context(), database.RevokePersonalAccessTokenParams{
+		ID:     tokenID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to revoke token: %v", err))
+		return
+	}
+	if revoked == 0 {
+		respondWithError(w, 404, "Token not found")
+		return
+	}
+
+	respondWithJSON(w, 200, map[string]string{
+		"message": "Token revoked successfully",
+	})
+}
@@ -1,11 +1,15 @@
 package main
 
 import (
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/mail"
+	"strings"
 	"time"
 
+	"github.com/Jayant-Verma/rssagg/internal/auth"
 	"github.com/Jayant-Verma/rssagg/internal/database"
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
@@ -50,6 +54,8 @@ func (apiCfg *apiConfig) handlerCreateUser(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	sendInBackground("verification email", user, apiCfg.sendEmailVerification)
+
 	respondWithJSON(w, 201, databaseUserToUser(user))
 }
 
@@ -57,15 +63,384 @@ func (apiCfg *apiConfig) handlerGetUser(w http.ResponseWriter, r *http.Request,
 	respondWithJSON(w, 200, databaseUserToUser(user))
 }
 
-func (apiCfg *apiConfig) handlerGetPostsForUser(w http.ResponseWriter, r *http.Request, user database.User) {
-	posts, err := apiCfg.DB.GetPostsForUser(r.Context(), database.GetPostsForUserParams{
+// handlerUpdateUser changes the user's name and email. A new email needs
+// the current password and has to be verified again; the old address is
+// told about the change and links mailed to it stop working.
+func (apiCfg *apiConfig) handlerUpdateUser(w http.ResponseWriter, r *http.Request, user database.User) {
+	type parameters struct {
+		Name            *string `json:"name"`
+		Email           *string `json:"email"`
+		CurrentPassword string  `json:"current_password"`
+	}
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Failed to decode request body: %v", err))
+		return
+	}
+
+	name, email := user.Name, user.Email
+	if params.Name != nil {
+		name = strings.TrimSpace(*params.Name)
+		if name == "" {
+			respondWithError(w, 400, "Name can't be empty")
+			return
+		}
+	}
+	if params.Email != nil {
+		email = strings.TrimSpace(*params.Email)
+		if email == "" {
+			respondWithError(w, 400, "Email can't be empty")
+			return
+		}
+		if !validEmail(email) {
+			respondWithError(w, 400, "Email is not a valid address")
+			return
+		}
+	}
+
+	emailChanged := email != user.Email
+	if emailChanged && bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(params.CurrentPassword)) != nil {
+		respondWithError(w, 403, "current_password is required to change the email address")
+		return
+	}
+
+	tx, err := apiCfg.Conn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to start transaction: %v", err))
+		return
+	}
+	defer tx.Rollback()
+	qtx := apiCfg.DB.WithTx(tx)
+
+	oldEmail := user.Email
+	user, err = qtx.UpdateUserProfile(r.Context(), database.UpdateUserProfileParams{
+		ID:    user.ID,
+		Name:  name,
+		Email: email,
+	})
+	if isUniqueViolation(err) {
+		respondWithError(w, 409, "Email is already in use")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to update user: %v", err))
+		return
+	}
+
+	// Links already mailed to the old address must not reset the password
+	// or verify the new address.
+	if emailChanged {
+		for _, purpose := range []string{userTokenPasswordReset, userTokenEmailVerification} {
+			err = qtx.InvalidateUserTokens(r.Context(), database.InvalidateUserTokensParams{
+				UserID:  user.ID,
+				Purpose: purpose,
+			})
+			if err != nil {
+				respondWithError(w, 500, fmt.Sprintf("Failed to invalidate mailed links: %v", err))
+				return
+			}
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to commit transaction: %v", err))
+		return
+	}
+
+	if emailChanged {
+		sendInBackground("email change notice", user, apiCfg.emailChangedNotice(oldEmail))
+		sendInBackground("verification email", user, apiCfg.sendEmailVerification)
+	}
+
+	respondWithJSON(w, 200, databaseUserToUser(user))
+}
+
+// validEmail reports whether email is a bare address such as
+// name@example.com, without a display name or angle brackets.
+func validEmail(email string) bool {
+	addr, err := mail.ParseAddress(email)
+	return err == nil && addr.Address == email
+}
+
+// handlerChangePassword sets a new password after checking the current one,
+// logging out every other session and revoking the user's API key and
+// personal access tokens.
+func (apiCfg *apiConfig) handlerChangePassword(w http.ResponseWriter, r *http.Request, user database.User) {
+	type parameters struct {
+		CurrentPassword string `json:"current_password"`
+		NewPassword     string `json:"new_password"`
+	}
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Failed to decode request body: %v", err))
+		return
+	}
+	if params.NewPassword == "" {
+		respondWithError(w, 400, "new_password is required")
+		return
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(params.CurrentPassword)) != nil {
+		respondWithError(w, 403, "Current password is incorrect")
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(params.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		respondWithError(w, 500, "Failed to hash password")
+		return
+	}
+
+	tx, err := apiCfg.Conn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to start transaction: %v", err))
+		return
+	}
+	defer tx.Rollback()
+	qtx := apiCfg.DB.WithTx(tx)
+
+	_, err = qtx.SetUserPasswordHash(r.Context(), database.SetUserPasswordHashParams{
+		ID:           user.ID,
+		PasswordHash: string(hashedPassword),
+	})
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to set password: %v", err))
+		return
+	}
+
+	err = qtx.InvalidateUserTokens(r.Context(), database.InvalidateUserTokensParams{
+		UserID:  user.ID,
+		Purpose: userTokenPasswordReset,
+	})
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to invalidate reset links: %v", err))
+		return
+	}
+
+	info, _ := authFromContext(r.Context())
+	_, err = qtx.RevokeSessionsForUser(r.Context(), database.RevokeSessionsForUserParams{
 		UserID: user.ID,
-		Limit:  50,
+		ID:     info.SessionID.UUID,
+	})
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to revoke sessions: %v", err))
+		return
+	}
+
+	// Credentials made with the old password go with it.
+	_, err = qtx.SetUserAPIKeyHash(r.Context(), database.SetUserAPIKeyHashParams{
+		ID: user.ID,
+	})
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to revoke API key: %v", err))
+		return
+	}
+	_, err = qtx.RevokePersonalAccessTokensForUser(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to revoke access tokens: %v", err))
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to commit transaction: %v", err))
+		return
+	}
+
+	respondWithJSON(w, 200, map[string]string{
+		"message": "Password changed successfully",
+	})
+}
+
+// accountDeletionTTL is how long the confirmation of an account deletion
+// stays valid.
+const accountDeletionTTL = 10 * time.Minute
+
+// handlerRequestAccountDeletion is the first step of deleting an account:
+// after the password is re-entered, it hands out the token that
+// DELETE /user/me must be called with.
+func (apiCfg *apiConfig) handlerRequestAccountDeletion(w http.ResponseWriter, r *http.Request, user database.User) {
+	type parameters struct {
+		Password string `json:"password"`
+	}
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Failed to decode request body: %v", err))
+		return
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(params.Password)) != nil {
+		respondWithError(w, 403, "Password is incorrect")
+		return
+	}
+
+	token, err := apiCfg.issueUserToken(r.Context(), user, userTokenAccountDeletion, accountDeletionTTL)
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to create confirmation token: %v", err))
+		return
+	}
+
+	respondWithJSON(w, 200, map[string]interface{}{
+		"confirmation_token": token,
+		"expires_at":         time.Now().UTC().Add(accountDeletionTTL),
+	})
+}
+
+// handlerDeleteUser deletes the account with the token from
+// handlerRequestAccountDeletion. Feeds the user added that others still
+// follow are handed to their earliest other follower first; everything
+// else of the user goes with the account.
+func (apiCfg *apiConfig) handlerDeleteUser(w http.ResponseWriter, r *http.Request, user database.User) {
+	type parameters struct {
+		ConfirmationToken string `json:"confirmation_token"`
+	}
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, 400, fmt.Sprintf("Failed to decode request body: %v", err))
+		return
+	}
+	if params.ConfirmationToken == "" {
+		respondWithError(w, 400, "confirmation_token is required; get one from POST /v1/user/me/deletion")
+		return
+	}
+
+	tx, err := apiCfg.Conn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to start transaction: %v", err))
+		return
+	}
+	defer tx.Rollback()
+	qtx := apiCfg.DB.WithTx(tx)
+
+	token, ok := useUserToken(w, r, qtx, params.ConfirmationToken, userTokenAccountDeletion)
+	if !ok {
+		return
+	}
+	if token.UserID != user.ID {
+		respondWithError(w, 400, "Invalid or expired token")
+		return
+	}
+
+	transferred, err := qtx.TransferOwnedFeeds(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to transfer feeds: %v", err))
+		return
+	}
+
+	_, err = qtx.DeleteUser(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to delete user: %v", err))
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to commit transaction: %v", err))
+		return
+	}
+
+	apiCfg.clearSessionCookies(w)
+	respondWithJSON(w, 200, map[string]interface{}{
+		"message":           "Account deleted successfully",
+		"feeds_transferred": transferred,
+	})
+}
+
+// handlerRotateFeedToken replaces the secret in the user's output feed URLs,
+// so anyone holding the old URLs loses access. Only its hash is stored, so
+// the token is only shown in this response.
+func (apiCfg *apiConfig) handlerRotateFeedToken(w http.ResponseWriter, r *http.Request, user database.User) {
+	token, err := auth.GenerateToken()
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to generate feed token: %v", err))
+		return
+	}
+
+	_, err = apiCfg.DB.SetUserFeedTokenHash(r.Context(), database.SetUserFeedTokenHashParams{
+		ID:            user.ID,
+		FeedTokenHash: sql.NullString{String: auth.HashToken(token), Valid: true},
+	})
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to rotate feed token: %v", err))
+		return
+	}
+
+	respondWithJSON(w, 201, map[string]string{
+		"feed_token": token,
 	})
+}
+
+// handlerGetPostsForUser lists posts a page at a time. The next_cursor of a
+// page is passed back as "before" when reading newest first, or as "after"
+// when reading with order=oldest.
+func (apiCfg *apiConfig) handlerGetPostsForUser(w http.ResponseWriter, r *http.Request, user database.User) {
+	filters, err := parsePostFilters(r.URL.Query())
 	if err != nil {
-		respondWithError(w, 500, fmt.Sprintf("Couldn't get posts: %v", err))
+		respondWithError(w, 400, err.Error())
 		return
 	}
 
-	respondWithJSON(w, 200, databasePostsToPosts(posts))
+	// One extra row tells us whether there is another page.
+	limit := int32(filters.Limit + 1)
+
+	var posts []Post
+	if filters.Starred {
+		posts, err = apiCfg.getStarredPostsPage(r.Context(), user, filters, limit)
+		if err != nil {
+			respondWithError(w, 500, fmt.Sprintf("Couldn't get starred posts: %v", err))
+			return
+		}
+	} else {
+		posts, err = apiCfg.getPostsPage(r.Context(), user, filters, limit)
+		if err != nil {
+			respondWithError(w, 500, fmt.Sprintf("Couldn't get posts: %v", err))
+			return
+		}
+	}
+
+	respondWithJSON(w, 200, newPostsPage(posts, filters.Limit))
+}
+
+// handlerRotateAPIKey issues a new API key, replacing any previous one. The
+// key is only ever returned by this call.
+func (apiCfg *apiConfig) handlerRotateAPIKey(w http.ResponseWriter, r *http.Request, user database.User) {
+	apiKey, err := auth.GenerateToken()
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to generate API key: %v", err))
+		return
+	}
+
+	_, err = apiCfg.DB.SetUserAPIKeyHash(r.Context(), database.SetUserAPIKeyHashParams{
+		ID:         user.ID,
+		ApiKeyHash: sql.NullString{String: auth.HashToken(apiKey), Valid: true},
+	})
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to rotate API key: %v", err))
+		return
+	}
+
+	respondWithJSON(w, 201, map[string]string{
+		"api_key": apiKey,
+	})
+}
+
+func (apiCfg *apiConfig) handlerDeleteAPIKey(w http.ResponseWriter, r *http.Request, user database.User) {
+	_, err := apiCfg.DB.SetUserAPIKeyHash(r.Context(), database.SetUserAPIKeyHashParams{
+		ID: user.ID,
+	})
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("Failed to delete API key: %v", err))
+		return
+	}
+
+	respondWithJSON(w, 200, map[string]string{
+		"message": "API key deleted successfully",
+	})
 }
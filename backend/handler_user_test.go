@@ -0,0 +1,24 @@
+package main
+
+import "testing"
+
+func TestValidEmail(t *testing.T) {
+	tests := []struct {
+		email string
+		want  bool
+	}{
+		{"reader@example.com", true},
+		{"first.last+news@mail.example.org", true},
+		{"reader", false},
+		{"reader@", false},
+		{"@example.com", false},
+		{"Reader <reader@example.com>", false},
+		{"reader@example.com, other@example.com", false},
+	}
+
+	for _, tt := range tests {
+		if got := validEmail(tt.email); got != tt.want {
+			t.Errorf("validEmail(%q) = %v, want %v", tt.email, got, tt.want)
+		}
+	}
+}
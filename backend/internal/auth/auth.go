@@ -1,6 +1,9 @@
 package auth
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"net/http"
 	"os"
@@ -33,7 +36,9 @@ func GetAPIKey(headers http.Header) (string, error) {
 	return vals[1], nil
 }
 
-func GetJWT(userID uuid.UUID) (string, error) {
+// GetJWT issues an access token for a login session. It is valid for ttl,
+// or until the session is revoked.
+func GetJWT(userID, sessionID uuid.UUID, ttl time.Duration) (string, error) {
 	godotenv.Load("../.env")
 
 	jwtSecret := []byte(os.Getenv("JWT_SECRET"))
@@ -43,9 +48,28 @@ func GetJWT(userID uuid.UUID) (string, error) {
 
 	claims := jwt.MapClaims{
 		"user_id": userID.String(),
-		"exp":     time.Now().Add(time.Hour * 24).Unix(),
+		"sid":     sessionID.String(),
+		"exp":     time.Now().Add(ttl).Unix(),
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString(jwtSecret)
 }
+
+// GenerateToken returns a random 256-bit secret encoded as 64 hex characters.
+func GenerateToken() (string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// HashToken returns the SHA-256 hex digest under which a generated secret is
+// stored. Secrets from GenerateToken are random enough that no salt or slow
+// hash is needed.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
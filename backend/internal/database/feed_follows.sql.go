@@ -7,6 +7,7 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
@@ -15,7 +16,7 @@ import (
 const createFeedFollow = `-- name: CreateFeedFollow :one
 INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id)
 VALUES ($1, $2, $3, $4, $5)
-RETURNING id, created_at, updated_at, user_id, feed_id
+RETURNING id, created_at, updated_at, user_id, feed_id, title, priority, muted, show_in_timeline, notifications, folder_id
 `
 
 type CreateFeedFollowParams struct {
@@ -41,6 +42,12 @@ func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowPara
 		&i.UpdatedAt,
 		&i.UserID,
 		&i.FeedID,
+		&i.Title,
+		&i.Priority,
+		&i.Muted,
+		&i.ShowInTimeline,
+		&i.Notifications,
+		&i.FolderID,
 	)
 	return i, err
 }
@@ -59,8 +66,112 @@ func (q *Queries) DeleteFeedFollow(ctx context.Context, arg DeleteFeedFollowPara
 	return err
 }
 
+const deleteFeedFollowByID = `-- name: DeleteFeedFollowByID :execrows
+DELETE FROM feed_follows WHERE id = $1 AND user_id = $2
+`
+
+type DeleteFeedFollowByIDParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) DeleteFeedFollowByID(ctx context.Context, arg DeleteFeedFollowByIDParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteFeedFollowByID, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const followFeed = `-- name: FollowFeed :one
+INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (user_id, feed_id) DO UPDATE SET updated_at = feed_follows.updated_at
+RETURNING id, created_at, updated_at, user_id, feed_id, title, priority, muted, show_in_timeline, notifications, folder_id
+`
+
+type FollowFeedParams struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	UserID    uuid.UUID
+	FeedID    uuid.UUID
+}
+
+func (q *Queries) FollowFeed(ctx context.Context, arg FollowFeedParams) (FeedFollow, error) {
+	row := q.db.QueryRowContext(ctx, followFeed,
+		arg.ID,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.UserID,
+		arg.FeedID,
+	)
+	var i FeedFollow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.FeedID,
+		&i.Title,
+		&i.Priority,
+		&i.Muted,
+		&i.ShowInTimeline,
+		&i.Notifications,
+		&i.FolderID,
+	)
+	return i, err
+}
+
+const getEarliestOtherFollower = `-- name: GetEarliestOtherFollower :one
+SELECT user_id FROM feed_follows
+WHERE feed_id = $1 AND user_id <> $2
+ORDER BY created_at ASC
+LIMIT 1
+`
+
+type GetEarliestOtherFollowerParams struct {
+	FeedID uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) GetEarliestOtherFollower(ctx context.Context, arg GetEarliestOtherFollowerParams) (uuid.UUID, error) {
+	row := q.db.QueryRowContext(ctx, getEarliestOtherFollower, arg.FeedID, arg.UserID)
+	var user_id uuid.UUID
+	err := row.Scan(&user_id)
+	return user_id, err
+}
+
+const getFeedFollowByID = `-- name: GetFeedFollowByID :one
+SELECT id, created_at, updated_at, user_id, feed_id, title, priority, muted, show_in_timeline, notifications, folder_id FROM feed_follows WHERE id = $1 AND user_id = $2
+`
+
+type GetFeedFollowByIDParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) GetFeedFollowByID(ctx context.Context, arg GetFeedFollowByIDParams) (FeedFollow, error) {
+	row := q.db.QueryRowContext(ctx, getFeedFollowByID, arg.ID, arg.UserID)
+	var i FeedFollow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.FeedID,
+		&i.Title,
+		&i.Priority,
+		&i.Muted,
+		&i.ShowInTimeline,
+		&i.Notifications,
+		&i.FolderID,
+	)
+	return i, err
+}
+
 const getFeedFollows = `-- name: GetFeedFollows :many
-SELECT id, created_at, updated_at, user_id, feed_id FROM feed_follows WHERE user_id = $1
+SELECT id, created_at, updated_at, user_id, feed_id, title, priority, muted, show_in_timeline, notifications, folder_id FROM feed_follows WHERE user_id = $1
 `
 
 func (q *Queries) GetFeedFollows(ctx context.Context, userID uuid.UUID) ([]FeedFollow, error) {
@@ -78,6 +189,12 @@ func (q *Queries) GetFeedFollows(ctx context.Context, userID uuid.UUID) ([]FeedF
 			&i.UpdatedAt,
 			&i.UserID,
 			&i.FeedID,
+			&i.Title,
+			&i.Priority,
+			&i.Muted,
+			&i.ShowInTimeline,
+			&i.Notifications,
+			&i.FolderID,
 		); err != nil {
 			return nil, err
 		}
@@ -91,3 +208,191 @@ func (q *Queries) GetFeedFollows(ctx context.Context, userID uuid.UUID) ([]FeedF
 	}
 	return items, nil
 }
+
+const getFeedFollowsForExport = `-- name: GetFeedFollowsForExport :many
+SELECT feed_follows.folder_id, COALESCE(feed_follows.title, feeds.name) AS title, feeds.url, feeds.site_url
+FROM feed_follows
+JOIN feeds ON feeds.id = feed_follows.feed_id
+WHERE feed_follows.user_id = $1
+ORDER BY title ASC
+`
+
+type GetFeedFollowsForExportRow struct {
+	FolderID uuid.NullUUID
+	Title    string
+	Url      string
+	SiteUrl  sql.NullString
+}
+
+func (q *Queries) GetFeedFollowsForExport(ctx context.Context, userID uuid.UUID) ([]GetFeedFollowsForExportRow, error) {
+	rows, err := q.db.QueryContext(ctx, getFeedFollowsForExport, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetFeedFollowsForExportRow
+	for rows.Next() {
+		var i GetFeedFollowsForExportRow
+		if err := rows.Scan(
+			&i.FolderID,
+			&i.Title,
+			&i.Url,
+			&i.SiteUrl,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFeedFollowsWithFeeds = `-- name: GetFeedFollowsWithFeeds :many
+SELECT feed_follows.id, feed_follows.created_at, feed_follows.updated_at, feed_follows.user_id, feed_follows.feed_id, feed_follows.title, feed_follows.priority, feed_follows.muted, feed_follows.show_in_timeline, feed_follows.notifications, feed_follows.folder_id, feeds.name AS feed_name, feeds.url AS feed_url, feeds.paused AS feed_paused
+FROM feed_follows
+JOIN feeds ON feeds.id = feed_follows.feed_id
+WHERE feed_follows.user_id = $1
+ORDER BY feed_follows.priority DESC, COALESCE(feed_follows.title, feeds.name) ASC
+`
+
+type GetFeedFollowsWithFeedsRow struct {
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	UserID         uuid.UUID
+	FeedID         uuid.UUID
+	Title          sql.NullString
+	Priority       int32
+	Muted          bool
+	ShowInTimeline bool
+	Notifications  string
+	FolderID       uuid.NullUUID
+	FeedName       string
+	FeedUrl        string
+	FeedPaused     bool
+}
+
+func (q *Queries) GetFeedFollowsWithFeeds(ctx context.Context, userID uuid.UUID) ([]GetFeedFollowsWithFeedsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getFeedFollowsWithFeeds, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetFeedFollowsWithFeedsRow
+	for rows.Next() {
+		var i GetFeedFollowsWithFeedsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.UserID,
+			&i.FeedID,
+			&i.Title,
+			&i.Priority,
+			&i.Muted,
+			&i.ShowInTimeline,
+			&i.Notifications,
+			&i.FolderID,
+			&i.FeedName,
+			&i.FeedUrl,
+			&i.FeedPaused,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setFeedFollowFolder = `-- name: SetFeedFollowFolder :one
+UPDATE feed_follows
+SET folder_id = $3,
+updated_at = NOW()
+WHERE id = $1 AND user_id = $2
+RETURNING id, created_at, updated_at, user_id, feed_id, title, priority, muted, show_in_timeline, notifications, folder_id
+`
+
+type SetFeedFollowFolderParams struct {
+	ID       uuid.UUID
+	UserID   uuid.UUID
+	FolderID uuid.NullUUID
+}
+
+func (q *Queries) SetFeedFollowFolder(ctx context.Context, arg SetFeedFollowFolderParams) (FeedFollow, error) {
+	row := q.db.QueryRowContext(ctx, setFeedFollowFolder, arg.ID, arg.UserID, arg.FolderID)
+	var i FeedFollow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.FeedID,
+		&i.Title,
+		&i.Priority,
+		&i.Muted,
+		&i.ShowInTimeline,
+		&i.Notifications,
+		&i.FolderID,
+	)
+	return i, err
+}
+
+const updateFeedFollowSettings = `-- name: UpdateFeedFollowSettings :one
+UPDATE feed_follows
+SET title = $3,
+priority = $4,
+muted = $5,
+show_in_timeline = $6,
+notifications = $7,
+updated_at = NOW()
+WHERE id = $1 AND user_id = $2
+RETURNING id, created_at, updated_at, user_id, feed_id, title, priority, muted, show_in_timeline, notifications, folder_id
+`
+
+type UpdateFeedFollowSettingsParams struct {
+	ID             uuid.UUID
+	UserID         uuid.UUID
+	Title          sql.NullString
+	Priority       int32
+	Muted          bool
+	ShowInTimeline bool
+	Notifications  string
+}
+
+func (q *Queries) UpdateFeedFollowSettings(ctx context.Context, arg UpdateFeedFollowSettingsParams) (FeedFollow, error) {
+	row := q.db.QueryRowContext(ctx, updateFeedFollowSettings,
+		arg.ID,
+		arg.UserID,
+		arg.Title,
+		arg.Priority,
+		arg.Muted,
+		arg.ShowInTimeline,
+		arg.Notifications,
+	)
+	var i FeedFollow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.FeedID,
+		&i.Title,
+		&i.Priority,
+		&i.Muted,
+		&i.ShowInTimeline,
+		&i.Notifications,
+		&i.FolderID,
+	)
+	return i, err
+}
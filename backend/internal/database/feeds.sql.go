@@ -7,6 +7,7 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
@@ -15,7 +16,7 @@ import (
 const createFeed = `-- name: CreateFeed :one
 INSERT INTO feeds (id, created_at, updated_at, name, url, user_id) 
 VALUES ($1, $2, $3, $4, $5, $6)
-RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, paused, language, site_url
 `
 
 type CreateFeedParams struct {
@@ -45,12 +46,96 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.Paused,
+		&i.Language,
+		&i.SiteUrl,
+	)
+	return i, err
+}
+
+const deleteFeed = `-- name: DeleteFeed :exec
+DELETE FROM feeds WHERE id = $1
+`
+
+func (q *Queries) DeleteFeed(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteFeed, id)
+	return err
+}
+
+const deleteUnfollowedFeeds = `-- name: DeleteUnfollowedFeeds :execrows
+DELETE FROM feeds
+WHERE created_at < $1
+AND NOT EXISTS (
+    SELECT 1 FROM feed_follows WHERE feed_follows.feed_id = feeds.id
+)
+AND NOT EXISTS (
+    SELECT 1 FROM posts
+    JOIN post_states ON post_states.post_id = posts.id
+    WHERE posts.feed_id = feeds.id AND post_states.starred
+)
+AND NOT EXISTS (
+    SELECT 1 FROM posts
+    WHERE posts.feed_id = feeds.id AND (
+        EXISTS (SELECT 1 FROM post_notes WHERE post_notes.post_id = posts.id)
+        OR EXISTS (SELECT 1 FROM post_highlights WHERE post_highlights.post_id = posts.id)
+    )
+)
+`
+
+func (q *Queries) DeleteUnfollowedFeeds(ctx context.Context, createdAt time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUnfollowedFeeds, createdAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getFeedByID = `-- name: GetFeedByID :one
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, paused, language, site_url FROM feeds WHERE id = $1
+`
+
+func (q *Queries) GetFeedByID(ctx context.Context, id uuid.UUID) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, getFeedByID, id)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.Paused,
+		&i.Language,
+		&i.SiteUrl,
+	)
+	return i, err
+}
+
+const getFeedByURL = `-- name: GetFeedByURL :one
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, paused, language, site_url FROM feeds WHERE url = $1
+`
+
+func (q *Queries) GetFeedByURL(ctx context.Context, url string) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, getFeedByURL, url)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.Paused,
+		&i.Language,
+		&i.SiteUrl,
 	)
 	return i, err
 }
 
 const getFeeds = `-- name: GetFeeds :many
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at FROM feeds
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, paused, language, site_url FROM feeds
 `
 
 func (q *Queries) GetFeeds(ctx context.Context) ([]Feed, error) {
@@ -70,6 +155,9 @@ func (q *Queries) GetFeeds(ctx context.Context) ([]Feed, error) {
 			&i.Url,
 			&i.UserID,
 			&i.LastFetchedAt,
+			&i.Paused,
+			&i.Language,
+			&i.SiteUrl,
 		); err != nil {
 			return nil, err
 		}
@@ -85,7 +173,8 @@ func (q *Queries) GetFeeds(ctx context.Context) ([]Feed, error) {
 }
 
 const getNextFeedsToFetchs = `-- name: GetNextFeedsToFetchs :many
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at FROM feeds 
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, paused, language, site_url FROM feeds 
+WHERE NOT paused
 ORDER BY last_fetched_at ASC NULLS FIRST
 LIMIT $1
 `
@@ -107,6 +196,9 @@ func (q *Queries) GetNextFeedsToFetchs(ctx context.Context, limit int32) ([]Feed
 			&i.Url,
 			&i.UserID,
 			&i.LastFetchedAt,
+			&i.Paused,
+			&i.Language,
+			&i.SiteUrl,
 		); err != nil {
 			return nil, err
 		}
@@ -126,7 +218,7 @@ UPDATE feeds
 SET last_fetched_at = NOW(),
 updated_at = NOW()
 WHERE id = $1
-RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, paused, language, site_url
 `
 
 func (q *Queries) MarkFeedAsFetched(ctx context.Context, id uuid.UUID) (Feed, error) {
@@ -140,6 +232,137 @@ func (q *Queries) MarkFeedAsFetched(ctx context.Context, id uuid.UUID) (Feed, er
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.Paused,
+		&i.Language,
+		&i.SiteUrl,
+	)
+	return i, err
+}
+
+const setFeedLanguage = `-- name: SetFeedLanguage :exec
+UPDATE feeds
+SET language = $2
+WHERE id = $1 AND language IS DISTINCT FROM $2
+`
+
+type SetFeedLanguageParams struct {
+	ID       uuid.UUID
+	Language sql.NullString
+}
+
+func (q *Queries) SetFeedLanguage(ctx context.Context, arg SetFeedLanguageParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedLanguage, arg.ID, arg.Language)
+	return err
+}
+
+const setFeedSiteURL = `-- name: SetFeedSiteURL :exec
+UPDATE feeds
+SET site_url = $2
+WHERE id = $1 AND site_url IS DISTINCT FROM $2
+`
+
+type SetFeedSiteURLParams struct {
+	ID      uuid.UUID
+	SiteUrl sql.NullString
+}
+
+func (q *Queries) SetFeedSiteURL(ctx context.Context, arg SetFeedSiteURLParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedSiteURL, arg.ID, arg.SiteUrl)
+	return err
+}
+
+const transferFeedOwnership = `-- name: TransferFeedOwnership :one
+UPDATE feeds
+SET user_id = $2,
+updated_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, paused, language, site_url
+`
+
+type TransferFeedOwnershipParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) TransferFeedOwnership(ctx context.Context, arg TransferFeedOwnershipParams) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, transferFeedOwnership, arg.ID, arg.UserID)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.Paused,
+		&i.Language,
+		&i.SiteUrl,
+	)
+	return i, err
+}
+
+const transferOwnedFeeds = `-- name: TransferOwnedFeeds :execrows
+UPDATE feeds
+SET user_id = successors.user_id,
+updated_at = NOW()
+FROM (
+    SELECT DISTINCT ON (feed_follows.feed_id) feed_follows.feed_id, feed_follows.user_id
+    FROM feed_follows
+    JOIN feeds ON feeds.id = feed_follows.feed_id
+    WHERE feeds.user_id = $1 AND feed_follows.user_id <> $1
+    ORDER BY feed_follows.feed_id, feed_follows.created_at ASC
+) AS successors
+WHERE feeds.id = successors.feed_id
+`
+
+func (q *Queries) TransferOwnedFeeds(ctx context.Context, userID uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, transferOwnedFeeds, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateFeed = `-- name: UpdateFeed :one
+UPDATE feeds
+SET name = $2,
+url = $3,
+paused = $4,
+last_fetched_at = $5,
+updated_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, paused, language, site_url
+`
+
+type UpdateFeedParams struct {
+	ID            uuid.UUID
+	Name          string
+	Url           string
+	Paused        bool
+	LastFetchedAt sql.NullTime
+}
+
+func (q *Queries) UpdateFeed(ctx context.Context, arg UpdateFeedParams) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, updateFeed,
+		arg.ID,
+		arg.Name,
+		arg.Url,
+		arg.Paused,
+		arg.LastFetchedAt,
+	)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.Paused,
+		&i.Language,
+		&i.SiteUrl,
 	)
 	return i, err
 }
@@ -19,14 +19,94 @@ type Feed struct {
 	Url           string
 	UserID        uuid.UUID
 	LastFetchedAt sql.NullTime
+	Paused        bool
+	Language      sql.NullString
+	SiteUrl       sql.NullString
 }
 
 type FeedFollow struct {
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	UserID         uuid.UUID
+	FeedID         uuid.UUID
+	Title          sql.NullString
+	Priority       int32
+	Muted          bool
+	ShowInTimeline bool
+	Notifications  string
+	FolderID       uuid.NullUUID
+}
+
+type FeedPostCount struct {
+	FeedID    uuid.UUID
+	PostCount int64
+}
+
+type FilterRule struct {
+	ID         uuid.UUID
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	UserID     uuid.UUID
+	FeedID     uuid.NullUUID
+	Name       string
+	MatchField string
+	MatchType  string
+	Pattern    string
+	Action     string
+	Tag        sql.NullString
+	Enabled    bool
+}
+
+type FilterRuleMatch struct {
+	FilterRuleID uuid.UUID
+	PostID       uuid.UUID
+	CreatedAt    time.Time
+}
+
+type Folder struct {
 	ID        uuid.UUID
 	CreatedAt time.Time
 	UpdatedAt time.Time
 	UserID    uuid.UUID
-	FeedID    uuid.UUID
+	ParentID  uuid.NullUUID
+	Name      string
+}
+
+type OpmlImport struct {
+	ID         uuid.UUID
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	UserID     uuid.UUID
+	Status     string
+	Total      int32
+	Error      sql.NullString
+	FinishedAt sql.NullTime
+}
+
+type OpmlImportItem struct {
+	ID           uuid.UUID
+	ImportID     uuid.UUID
+	Position     int32
+	Title        string
+	Url          string
+	ParentFolder sql.NullString
+	Folder       sql.NullString
+	Status       string
+	FeedID       uuid.NullUUID
+	Error        sql.NullString
+}
+
+type PersonalAccessToken struct {
+	ID         uuid.UUID
+	CreatedAt  time.Time
+	UserID     uuid.UUID
+	Name       string
+	TokenHash  string
+	Scopes     string
+	ExpiresAt  sql.NullTime
+	LastUsedAt sql.NullTime
+	RevokedAt  sql.NullTime
 }
 
 type Post struct {
@@ -38,14 +118,118 @@ type Post struct {
 	PublishedAt time.Time
 	Url         string
 	FeedID      uuid.UUID
+	Content     sql.NullString
+	Author      sql.NullString
+	Categories  sql.NullString
+}
+
+type PostHighlight struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	UserID      uuid.UUID
+	PostID      uuid.UUID
+	Quote       string
+	StartOffset sql.NullInt32
+	EndOffset   sql.NullInt32
+	Comment     sql.NullString
+}
+
+type PostNote struct {
+	UserID    uuid.UUID
+	PostID    uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Body      string
+}
+
+type PostSearch struct {
+	PostID   uuid.UUID
+	Config   interface{}
+	Document interface{}
+}
+
+type PostState struct {
+	UserID    uuid.UUID
+	PostID    uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Read      bool
+	ReadAt    sql.NullTime
+	FeedID    uuid.UUID
+	Starred   bool
+	StarredAt sql.NullTime
+	Hidden    bool
+}
+
+type PostTag struct {
+	TagID     uuid.UUID
+	PostID    uuid.UUID
+	CreatedAt time.Time
+}
+
+type RefreshToken struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	SessionID uuid.UUID
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    sql.NullTime
+}
+
+type SavedSearch struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	UserID    uuid.UUID
+	Name      string
+	Query     string
+	Lang      string
+}
+
+type SavedSearchMatch struct {
+	SavedSearchID uuid.UUID
+	PostID        uuid.UUID
+	CreatedAt     time.Time
+}
+
+type Session struct {
+	ID         uuid.UUID
+	CreatedAt  time.Time
+	UserID     uuid.UUID
+	ExpiresAt  time.Time
+	RevokedAt  sql.NullTime
+	UserAgent  string
+	Ip         string
+	LastSeenAt time.Time
+}
+
+type Tag struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UserID    uuid.UUID
+	Name      string
 }
 
 type User struct {
-	ID           uuid.UUID
-	CreatedAt    time.Time
-	UpdatedAt    time.Time
-	Name         string
-	ApiKey       string
-	Email        string
-	PasswordHash string
+	ID              uuid.UUID
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	Name            string
+	ApiKeyHash      sql.NullString
+	Email           string
+	PasswordHash    string
+	IsAdmin         bool
+	EmailVerifiedAt sql.NullTime
+	FeedTokenHash   sql.NullString
+}
+
+type UserToken struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UserID    uuid.UUID
+	Purpose   string
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    sql.NullTime
 }
@@ -14,9 +14,9 @@ import (
 )
 
 const createPost = `-- name: CreatePost :one
-INSERT INTO posts (id, created_at, updated_at, title, description, published_at, url, feed_id)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-RETURNING id, created_at, updated_at, title, description, published_at, url, feed_id
+INSERT INTO posts (id, created_at, updated_at, title, description, published_at, url, feed_id, content, author, categories)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+RETURNING id, created_at, updated_at, title, description, published_at, url, feed_id, content, author, categories
 `
 
 type CreatePostParams struct {
@@ -28,6 +28,9 @@ type CreatePostParams struct {
 	PublishedAt time.Time
 	Url         string
 	FeedID      uuid.UUID
+	Content     sql.NullString
+	Author      sql.NullString
+	Categories  sql.NullString
 }
 
 func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, error) {
@@ -40,6 +43,9 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, e
 		arg.PublishedAt,
 		arg.Url,
 		arg.FeedID,
+		arg.Content,
+		arg.Author,
+		arg.Categories,
 	)
 	var i Post
 	err := row.Scan(
@@ -51,32 +57,578 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, e
 		&i.PublishedAt,
 		&i.Url,
 		&i.FeedID,
+		&i.Content,
+		&i.Author,
+		&i.Categories,
+	)
+	return i, err
+}
+
+const deleteOldPosts = `-- name: DeleteOldPosts :execrows
+DELETE FROM posts
+WHERE published_at < $1
+AND NOT EXISTS (
+    SELECT 1 FROM post_states
+    WHERE post_states.post_id = posts.id AND post_states.starred
+)
+AND NOT EXISTS (SELECT 1 FROM post_notes WHERE post_notes.post_id = posts.id)
+AND NOT EXISTS (SELECT 1 FROM post_highlights WHERE post_highlights.post_id = posts.id)
+`
+
+func (q *Queries) DeleteOldPosts(ctx context.Context, publishedAt time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteOldPosts, publishedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getPostForUser = `-- name: GetPostForUser :one
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.description, posts.published_at, posts.url, posts.feed_id, posts.content, posts.author, posts.categories FROM posts
+WHERE posts.id = $1
+AND (
+    EXISTS (
+        SELECT 1 FROM feed_follows
+        WHERE feed_follows.feed_id = posts.feed_id AND feed_follows.user_id = $2
+    )
+    OR EXISTS (
+        SELECT 1 FROM post_states
+        WHERE post_states.post_id = posts.id AND post_states.user_id = $2
+    )
+)
+`
+
+type GetPostForUserParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) GetPostForUser(ctx context.Context, arg GetPostForUserParams) (Post, error) {
+	row := q.db.QueryRowContext(ctx, getPostForUser, arg.ID, arg.UserID)
+	var i Post
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Title,
+		&i.Description,
+		&i.PublishedAt,
+		&i.Url,
+		&i.FeedID,
+		&i.Content,
+		&i.Author,
+		&i.Categories,
 	)
 	return i, err
 }
 
 const getPostsForUser = `-- name: GetPostsForUser :many
-SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.description, posts.published_at, posts.url, posts.feed_id FROM posts 
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.description, posts.published_at, posts.url, posts.feed_id, posts.content, posts.author, posts.categories, COALESCE(post_states.read, false) AS read, post_states.read_at,
+    COALESCE(post_states.starred, false) AS starred, post_states.starred_at
+FROM posts 
 JOIN feed_follows ON posts.feed_id = feed_follows.feed_id
+LEFT JOIN post_states ON post_states.post_id = posts.id AND post_states.user_id = feed_follows.user_id
 WHERE feed_follows.user_id = $1
-ORDER BY published_at DESC
-LIMIT $2
+AND NOT COALESCE(post_states.hidden, false)
+AND ($2::uuid IS NOT NULL OR $3::text IS NOT NULL OR NOT feed_follows.muted)
+AND ($2::uuid IS NOT NULL OR $4::uuid IS NOT NULL
+    OR $3::text IS NOT NULL OR feed_follows.show_in_timeline)
+AND ($2::uuid IS NULL OR posts.feed_id = $2)
+AND ($4::uuid IS NULL OR feed_follows.folder_id IN (
+    SELECT folders.id FROM folders
+    WHERE folders.id = $4 OR folders.parent_id = $4
+))
+AND ($5::timestamp IS NULL OR posts.published_at >= $5)
+AND ($6::timestamp IS NULL OR posts.published_at < $6)
+AND ($7::boolean IS NULL OR COALESCE(post_states.read, false) = $7)
+AND ($8::timestamp IS NULL
+    OR (posts.published_at, posts.id) < ($8, $9::uuid))
+AND ($3::text IS NULL OR EXISTS (
+    SELECT 1 FROM post_tags
+    JOIN tags ON tags.id = post_tags.tag_id
+    WHERE post_tags.post_id = posts.id AND tags.user_id = $1 AND tags.name = $3
+))
+ORDER BY posts.published_at DESC, posts.id DESC
+LIMIT $10
 `
 
 type GetPostsForUserParams struct {
-	UserID uuid.UUID
-	Limit  int32
+	UserID            uuid.UUID
+	FeedID            uuid.NullUUID
+	Tag               sql.NullString
+	FolderID          uuid.NullUUID
+	Since             sql.NullTime
+	Until             sql.NullTime
+	Read              sql.NullBool
+	BeforePublishedAt sql.NullTime
+	BeforeID          uuid.NullUUID
+	Limit             int32
+}
+
+type GetPostsForUserRow struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Title       string
+	Description sql.NullString
+	PublishedAt time.Time
+	Url         string
+	FeedID      uuid.UUID
+	Content     sql.NullString
+	Author      sql.NullString
+	Categories  sql.NullString
+	Read        bool
+	ReadAt      sql.NullTime
+	Starred     bool
+	StarredAt   sql.NullTime
+}
+
+func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams) ([]GetPostsForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPostsForUser,
+		arg.UserID,
+		arg.FeedID,
+		arg.Tag,
+		arg.FolderID,
+		arg.Since,
+		arg.Until,
+		arg.Read,
+		arg.BeforePublishedAt,
+		arg.BeforeID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPostsForUserRow
+	for rows.Next() {
+		var i GetPostsForUserRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Description,
+			&i.PublishedAt,
+			&i.Url,
+			&i.FeedID,
+			&i.Content,
+			&i.Author,
+			&i.Categories,
+			&i.Read,
+			&i.ReadAt,
+			&i.Starred,
+			&i.StarredAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPostsForUserOldestFirst = `-- name: GetPostsForUserOldestFirst :many
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.description, posts.published_at, posts.url, posts.feed_id, posts.content, posts.author, posts.categories, COALESCE(post_states.read, false) AS read, post_states.read_at,
+    COALESCE(post_states.starred, false) AS starred, post_states.starred_at
+FROM posts 
+JOIN feed_follows ON posts.feed_id = feed_follows.feed_id
+LEFT JOIN post_states ON post_states.post_id = posts.id AND post_states.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = $1
+AND NOT COALESCE(post_states.hidden, false)
+AND ($2::uuid IS NOT NULL OR $3::text IS NOT NULL OR NOT feed_follows.muted)
+AND ($2::uuid IS NOT NULL OR $4::uuid IS NOT NULL
+    OR $3::text IS NOT NULL OR feed_follows.show_in_timeline)
+AND ($2::uuid IS NULL OR posts.feed_id = $2)
+AND ($4::uuid IS NULL OR feed_follows.folder_id IN (
+    SELECT folders.id FROM folders
+    WHERE folders.id = $4 OR folders.parent_id = $4
+))
+AND ($5::timestamp IS NULL OR posts.published_at >= $5)
+AND ($6::timestamp IS NULL OR posts.published_at < $6)
+AND ($7::boolean IS NULL OR COALESCE(post_states.read, false) = $7)
+AND ($8::timestamp IS NULL
+    OR (posts.published_at, posts.id) > ($8, $9::uuid))
+AND ($3::text IS NULL OR EXISTS (
+    SELECT 1 FROM post_tags
+    JOIN tags ON tags.id = post_tags.tag_id
+    WHERE post_tags.post_id = posts.id AND tags.user_id = $1 AND tags.name = $3
+))
+ORDER BY posts.published_at ASC, posts.id ASC
+LIMIT $10
+`
+
+type GetPostsForUserOldestFirstParams struct {
+	UserID           uuid.UUID
+	FeedID           uuid.NullUUID
+	Tag              sql.NullString
+	FolderID         uuid.NullUUID
+	Since            sql.NullTime
+	Until            sql.NullTime
+	Read             sql.NullBool
+	AfterPublishedAt sql.NullTime
+	AfterID          uuid.NullUUID
+	Limit            int32
+}
+
+type GetPostsForUserOldestFirstRow struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Title       string
+	Description sql.NullString
+	PublishedAt time.Time
+	Url         string
+	FeedID      uuid.UUID
+	Content     sql.NullString
+	Author      sql.NullString
+	Categories  sql.NullString
+	Read        bool
+	ReadAt      sql.NullTime
+	Starred     bool
+	StarredAt   sql.NullTime
+}
+
+func (q *Queries) GetPostsForUserOldestFirst(ctx context.Context, arg GetPostsForUserOldestFirstParams) ([]GetPostsForUserOldestFirstRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPostsForUserOldestFirst,
+		arg.UserID,
+		arg.FeedID,
+		arg.Tag,
+		arg.FolderID,
+		arg.Since,
+		arg.Until,
+		arg.Read,
+		arg.AfterPublishedAt,
+		arg.AfterID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPostsForUserOldestFirstRow
+	for rows.Next() {
+		var i GetPostsForUserOldestFirstRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Description,
+			&i.PublishedAt,
+			&i.Url,
+			&i.FeedID,
+			&i.Content,
+			&i.Author,
+			&i.Categories,
+			&i.Read,
+			&i.ReadAt,
+			&i.Starred,
+			&i.StarredAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getStarredPostsForUser = `-- name: GetStarredPostsForUser :many
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.description, posts.published_at, posts.url, posts.feed_id, posts.content, posts.author, posts.categories, post_states.read, post_states.read_at, post_states.starred_at
+FROM post_states
+JOIN posts ON posts.id = post_states.post_id
+LEFT JOIN feed_follows ON feed_follows.feed_id = posts.feed_id AND feed_follows.user_id = post_states.user_id
+WHERE post_states.user_id = $1
+AND post_states.starred
+AND ($2::uuid IS NULL OR posts.feed_id = $2)
+AND ($3::uuid IS NULL OR feed_follows.folder_id IN (
+    SELECT folders.id FROM folders
+    WHERE folders.id = $3 OR folders.parent_id = $3
+))
+AND ($4::timestamp IS NULL OR posts.published_at >= $4)
+AND ($5::timestamp IS NULL OR posts.published_at < $5)
+AND ($6::boolean IS NULL OR COALESCE(post_states.read, false) = $6)
+AND ($7::timestamp IS NULL
+    OR (posts.published_at, posts.id) < ($7, $8::uuid))
+AND ($9::text IS NULL OR EXISTS (
+    SELECT 1 FROM post_tags
+    JOIN tags ON tags.id = post_tags.tag_id
+    WHERE post_tags.post_id = posts.id AND tags.user_id = $1 AND tags.name = $9
+))
+ORDER BY posts.published_at DESC, posts.id DESC
+LIMIT $10
+`
+
+type GetStarredPostsForUserParams struct {
+	UserID            uuid.UUID
+	FeedID            uuid.NullUUID
+	FolderID          uuid.NullUUID
+	Since             sql.NullTime
+	Until             sql.NullTime
+	Read              sql.NullBool
+	BeforePublishedAt sql.NullTime
+	BeforeID          uuid.NullUUID
+	Tag               sql.NullString
+	Limit             int32
+}
+
+type GetStarredPostsForUserRow struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Title       string
+	Description sql.NullString
+	PublishedAt time.Time
+	Url         string
+	FeedID      uuid.UUID
+	Content     sql.NullString
+	Author      sql.NullString
+	Categories  sql.NullString
+	Read        bool
+	ReadAt      sql.NullTime
+	StarredAt   sql.NullTime
+}
+
+func (q *Queries) GetStarredPostsForUser(ctx context.Context, arg GetStarredPostsForUserParams) ([]GetStarredPostsForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, getStarredPostsForUser,
+		arg.UserID,
+		arg.FeedID,
+		arg.FolderID,
+		arg.Since,
+		arg.Until,
+		arg.Read,
+		arg.BeforePublishedAt,
+		arg.BeforeID,
+		arg.Tag,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetStarredPostsForUserRow
+	for rows.Next() {
+		var i GetStarredPostsForUserRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Description,
+			&i.PublishedAt,
+			&i.Url,
+			&i.FeedID,
+			&i.Content,
+			&i.Author,
+			&i.Categories,
+			&i.Read,
+			&i.ReadAt,
+			&i.StarredAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams) ([]Post, error) {
-	rows, err := q.db.QueryContext(ctx, getPostsForUser, arg.UserID, arg.Limit)
+const getStarredPostsForUserOldestFirst = `-- name: GetStarredPostsForUserOldestFirst :many
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.description, posts.published_at, posts.url, posts.feed_id, posts.content, posts.author, posts.categories, post_states.read, post_states.read_at, post_states.starred_at
+FROM post_states
+JOIN posts ON posts.id = post_states.post_id
+LEFT JOIN feed_follows ON feed_follows.feed_id = posts.feed_id AND feed_follows.user_id = post_states.user_id
+WHERE post_states.user_id = $1
+AND post_states.starred
+AND ($2::uuid IS NULL OR posts.feed_id = $2)
+AND ($3::uuid IS NULL OR feed_follows.folder_id IN (
+    SELECT folders.id FROM folders
+    WHERE folders.id = $3 OR folders.parent_id = $3
+))
+AND ($4::timestamp IS NULL OR posts.published_at >= $4)
+AND ($5::timestamp IS NULL OR posts.published_at < $5)
+AND ($6::boolean IS NULL OR COALESCE(post_states.read, false) = $6)
+AND ($7::timestamp IS NULL
+    OR (posts.published_at, posts.id) > ($7, $8::uuid))
+AND ($9::text IS NULL OR EXISTS (
+    SELECT 1 FROM post_tags
+    JOIN tags ON tags.id = post_tags.tag_id
+    WHERE post_tags.post_id = posts.id AND tags.user_id = $1 AND tags.name = $9
+))
+ORDER BY posts.published_at ASC, posts.id ASC
+LIMIT $10
+`
+
+type GetStarredPostsForUserOldestFirstParams struct {
+	UserID           uuid.UUID
+	FeedID           uuid.NullUUID
+	FolderID         uuid.NullUUID
+	Since            sql.NullTime
+	Until            sql.NullTime
+	Read             sql.NullBool
+	AfterPublishedAt sql.NullTime
+	AfterID          uuid.NullUUID
+	Tag              sql.NullString
+	Limit            int32
+}
+
+type GetStarredPostsForUserOldestFirstRow struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Title       string
+	Description sql.NullString
+	PublishedAt time.Time
+	Url         string
+	FeedID      uuid.UUID
+	Content     sql.NullString
+	Author      sql.NullString
+	Categories  sql.NullString
+	Read        bool
+	ReadAt      sql.NullTime
+	StarredAt   sql.NullTime
+}
+
+func (q *Queries) GetStarredPostsForUserOldestFirst(ctx context.Context, arg GetStarredPostsForUserOldestFirstParams) ([]GetStarredPostsForUserOldestFirstRow, error) {
+	rows, err := q.db.QueryContext(ctx, getStarredPostsForUserOldestFirst,
+		arg.UserID,
+		arg.FeedID,
+		arg.FolderID,
+		arg.Since,
+		arg.Until,
+		arg.Read,
+		arg.AfterPublishedAt,
+		arg.AfterID,
+		arg.Tag,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetStarredPostsForUserOldestFirstRow
+	for rows.Next() {
+		var i GetStarredPostsForUserOldestFirstRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Description,
+			&i.PublishedAt,
+			&i.Url,
+			&i.FeedID,
+			&i.Content,
+			&i.Author,
+			&i.Categories,
+			&i.Read,
+			&i.ReadAt,
+			&i.StarredAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchPostsForUser = `-- name: SearchPostsForUser :many
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.description, posts.published_at, posts.url, posts.feed_id, posts.content, posts.author, posts.categories, COALESCE(post_states.read, false) AS read, post_states.read_at,
+    COALESCE(post_states.starred, false) AS starred, post_states.starred_at,
+    ts_rank(post_search.document, search.query)::real AS rank,
+    ts_headline(post_search.config, escape_html(posts.title), search.query,
+        'HighlightAll=true, StartSel=<mark>, StopSel=</mark>') AS title_highlight,
+    ts_headline(post_search.config, escape_html(strip_html(COALESCE(NULLIF(posts.content, ''), posts.description))), search.query,
+        'StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=30, MinWords=10') AS snippet
+FROM post_search
+JOIN posts ON posts.id = post_search.post_id
+JOIN feed_follows ON feed_follows.feed_id = posts.feed_id AND feed_follows.user_id = $1
+LEFT JOIN post_states ON post_states.post_id = posts.id AND post_states.user_id = feed_follows.user_id
+CROSS JOIN (
+    SELECT websearch_to_tsquery('simple', $2)
+        || websearch_to_tsquery(search_config($3), $2) AS query
+) AS search
+WHERE post_search.document @@ search.query
+AND NOT COALESCE(post_states.hidden, false)
+AND ($4::uuid IS NULL OR posts.feed_id = $4)
+AND ($5::uuid IS NULL OR feed_follows.folder_id IN (
+    SELECT folders.id FROM folders
+    WHERE folders.id = $5 OR folders.parent_id = $5
+))
+ORDER BY rank DESC, posts.published_at DESC, posts.id DESC
+LIMIT $6 OFFSET $7
+`
+
+type SearchPostsForUserParams struct {
+	UserID   uuid.UUID
+	Query    string
+	Lang     string
+	FeedID   uuid.NullUUID
+	FolderID uuid.NullUUID
+	Limit    int32
+	Offset   int32
+}
+
+type SearchPostsForUserRow struct {
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	Title          string
+	Description    sql.NullString
+	PublishedAt    time.Time
+	Url            string
+	FeedID         uuid.UUID
+	Content        sql.NullString
+	Author         sql.NullString
+	Categories     sql.NullString
+	Read           bool
+	ReadAt         sql.NullTime
+	Starred        bool
+	StarredAt      sql.NullTime
+	Rank           float32
+	TitleHighlight string
+	Snippet        string
+}
+
+func (q *Queries) SearchPostsForUser(ctx context.Context, arg SearchPostsForUserParams) ([]SearchPostsForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchPostsForUser,
+		arg.UserID,
+		arg.Query,
+		arg.Lang,
+		arg.FeedID,
+		arg.FolderID,
+		arg.Limit,
+		arg.Offset,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Post
+	var items []SearchPostsForUserRow
 	for rows.Next() {
-		var i Post
+		var i SearchPostsForUserRow
 		if err := rows.Scan(
 			&i.ID,
 			&i.CreatedAt,
@@ -86,6 +638,16 @@ func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams
 			&i.PublishedAt,
 			&i.Url,
 			&i.FeedID,
+			&i.Content,
+			&i.Author,
+			&i.Categories,
+			&i.Read,
+			&i.ReadAt,
+			&i.Starred,
+			&i.StarredAt,
+			&i.Rank,
+			&i.TitleHighlight,
+			&i.Snippet,
 		); err != nil {
 			return nil, err
 		}
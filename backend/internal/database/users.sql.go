@@ -7,17 +7,16 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 const createUser = `-- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, name, email, password_hash, api_key) 
-VALUES ($1, $2, $3, $4, $5, $6,
-    encode(sha256(random()::text::bytea), 'hex')
-)
-RETURNING id, created_at, updated_at, name, api_key, email, password_hash
+INSERT INTO users (id, created_at, updated_at, name, email, password_hash)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, created_at, updated_at, name, api_key_hash, email, password_hash, is_admin, email_verified_at, feed_token_hash
 `
 
 type CreateUserParams struct {
@@ -44,34 +43,52 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Name,
-		&i.ApiKey,
+		&i.ApiKeyHash,
 		&i.Email,
 		&i.PasswordHash,
+		&i.IsAdmin,
+		&i.EmailVerifiedAt,
+		&i.FeedTokenHash,
 	)
 	return i, err
 }
 
-const getUserByAPIKey = `-- name: GetUserByAPIKey :one
-SELECT id, created_at, updated_at, name, api_key, email, password_hash FROM users WHERE api_key = $1
+const deleteUser = `-- name: DeleteUser :execrows
+DELETE FROM users WHERE id = $1
 `
 
-func (q *Queries) GetUserByAPIKey(ctx context.Context, apiKey string) (User, error) {
-	row := q.db.QueryRowContext(ctx, getUserByAPIKey, apiKey)
+func (q *Queries) DeleteUser(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUser, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getUserByAPIKeyHash = `-- name: GetUserByAPIKeyHash :one
+SELECT id, created_at, updated_at, name, api_key_hash, email, password_hash, is_admin, email_verified_at, feed_token_hash FROM users WHERE api_key_hash = $1
+`
+
+func (q *Queries) GetUserByAPIKeyHash(ctx context.Context, apiKeyHash sql.NullString) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByAPIKeyHash, apiKeyHash)
 	var i User
 	err := row.Scan(
 		&i.ID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Name,
-		&i.ApiKey,
+		&i.ApiKeyHash,
 		&i.Email,
 		&i.PasswordHash,
+		&i.IsAdmin,
+		&i.EmailVerifiedAt,
+		&i.FeedTokenHash,
 	)
 	return i, err
 }
 
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, created_at, updated_at, name, api_key, email, password_hash FROM users WHERE email = $1
+SELECT id, created_at, updated_at, name, api_key_hash, email, password_hash, is_admin, email_verified_at, feed_token_hash FROM users WHERE email = $1
 `
 
 func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
@@ -82,15 +99,40 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Name,
-		&i.ApiKey,
+		&i.ApiKeyHash,
 		&i.Email,
 		&i.PasswordHash,
+		&i.IsAdmin,
+		&i.EmailVerifiedAt,
+		&i.FeedTokenHash,
+	)
+	return i, err
+}
+
+const getUserByFeedTokenHash = `-- name: GetUserByFeedTokenHash :one
+SELECT id, created_at, updated_at, name, api_key_hash, email, password_hash, is_admin, email_verified_at, feed_token_hash FROM users WHERE feed_token_hash = $1
+`
+
+func (q *Queries) GetUserByFeedTokenHash(ctx context.Context, feedTokenHash sql.NullString) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByFeedTokenHash, feedTokenHash)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.ApiKeyHash,
+		&i.Email,
+		&i.PasswordHash,
+		&i.IsAdmin,
+		&i.EmailVerifiedAt,
+		&i.FeedTokenHash,
 	)
 	return i, err
 }
 
 const getUserByID = `-- name: GetUserByID :one
-SELECT id, created_at, updated_at, name, api_key, email, password_hash FROM users WHERE id = $1
+SELECT id, created_at, updated_at, name, api_key_hash, email, password_hash, is_admin, email_verified_at, feed_token_hash FROM users WHERE id = $1
 `
 
 func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
@@ -101,9 +143,165 @@ func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Name,
-		&i.ApiKey,
+		&i.ApiKeyHash,
+		&i.Email,
+		&i.PasswordHash,
+		&i.IsAdmin,
+		&i.EmailVerifiedAt,
+		&i.FeedTokenHash,
+	)
+	return i, err
+}
+
+const setUserAPIKeyHash = `-- name: SetUserAPIKeyHash :one
+UPDATE users
+SET api_key_hash = $2,
+updated_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, name, api_key_hash, email, password_hash, is_admin, email_verified_at, feed_token_hash
+`
+
+type SetUserAPIKeyHashParams struct {
+	ID         uuid.UUID
+	ApiKeyHash sql.NullString
+}
+
+func (q *Queries) SetUserAPIKeyHash(ctx context.Context, arg SetUserAPIKeyHashParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, setUserAPIKeyHash, arg.ID, arg.ApiKeyHash)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.ApiKeyHash,
+		&i.Email,
+		&i.PasswordHash,
+		&i.IsAdmin,
+		&i.EmailVerifiedAt,
+		&i.FeedTokenHash,
+	)
+	return i, err
+}
+
+const setUserEmailVerified = `-- name: SetUserEmailVerified :one
+UPDATE users
+SET email_verified_at = NOW(),
+updated_at = NOW()
+WHERE id = $1 AND email_verified_at IS NULL
+RETURNING id, created_at, updated_at, name, api_key_hash, email, password_hash, is_admin, email_verified_at, feed_token_hash
+`
+
+func (q *Queries) SetUserEmailVerified(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, setUserEmailVerified, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.ApiKeyHash,
+		&i.Email,
+		&i.PasswordHash,
+		&i.IsAdmin,
+		&i.EmailVerifiedAt,
+		&i.FeedTokenHash,
+	)
+	return i, err
+}
+
+const setUserFeedTokenHash = `-- name: SetUserFeedTokenHash :one
+UPDATE users
+SET feed_token_hash = $2,
+updated_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, name, api_key_hash, email, password_hash, is_admin, email_verified_at, feed_token_hash
+`
+
+type SetUserFeedTokenHashParams struct {
+	ID            uuid.UUID
+	FeedTokenHash sql.NullString
+}
+
+func (q *Queries) SetUserFeedTokenHash(ctx context.Context, arg SetUserFeedTokenHashParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, setUserFeedTokenHash, arg.ID, arg.FeedTokenHash)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.ApiKeyHash,
+		&i.Email,
+		&i.PasswordHash,
+		&i.IsAdmin,
+		&i.EmailVerifiedAt,
+		&i.FeedTokenHash,
+	)
+	return i, err
+}
+
+const setUserPasswordHash = `-- name: SetUserPasswordHash :one
+UPDATE users
+SET password_hash = $2,
+updated_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, name, api_key_hash, email, password_hash, is_admin, email_verified_at, feed_token_hash
+`
+
+type SetUserPasswordHashParams struct {
+	ID           uuid.UUID
+	PasswordHash string
+}
+
+func (q *Queries) SetUserPasswordHash(ctx context.Context, arg SetUserPasswordHashParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, setUserPasswordHash, arg.ID, arg.PasswordHash)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.ApiKeyHash,
+		&i.Email,
+		&i.PasswordHash,
+		&i.IsAdmin,
+		&i.EmailVerifiedAt,
+		&i.FeedTokenHash,
+	)
+	return i, err
+}
+
+const updateUserProfile = `-- name: UpdateUserProfile :one
+UPDATE users
+SET name = $2,
+email = $3,
+email_verified_at = CASE WHEN email = $3 THEN email_verified_at END,
+updated_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, name, api_key_hash, email, password_hash, is_admin, email_verified_at, feed_token_hash
+`
+
+type UpdateUserProfileParams struct {
+	ID    uuid.UUID
+	Name  string
+	Email string
+}
+
+func (q *Queries) UpdateUserProfile(ctx context.Context, arg UpdateUserProfileParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserProfile, arg.ID, arg.Name, arg.Email)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.ApiKeyHash,
 		&i.Email,
 		&i.PasswordHash,
+		&i.IsAdmin,
+		&i.EmailVerifiedAt,
+		&i.FeedTokenHash,
 	)
 	return i, err
 }
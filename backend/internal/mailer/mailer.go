@@ -0,0 +1,154 @@
+// Package mailer sends the emails the API needs, such as password resets
+// and address verification, through SMTP or a stand-in for development.
+package mailer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Message is a plain-text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender delivers messages.
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// FromEnv builds the Sender selected by MAIL_SENDER: "smtp", "file" or
+// "log", configured by the variables each sender documents. Emails carry
+// account secrets, so when MAIL_SENDER is unset nothing is sent and only
+// each message's recipient and subject are logged.
+func FromEnv() (Sender, error) {
+	from := os.Getenv("MAIL_FROM")
+	if from == "" {
+		from = "RSS Aggregator <no-reply@localhost>"
+	}
+
+	switch kind := os.Getenv("MAIL_SENDER"); kind {
+	case "":
+		log.Print("WARNING: MAIL_SENDER is not set, so emails such as password resets are not delivered. " +
+			"Set MAIL_SENDER=smtp to send them, or MAIL_SENDER=log to log them in full during development.")
+		return LogSender{Redact: true}, nil
+	case "log":
+		log.Print("WARNING: MAIL_SENDER=log writes emails, including password reset links, to the log. Don't use it in production.")
+		return LogSender{}, nil
+	case "file":
+		dir := os.Getenv("MAIL_DIR")
+		if dir == "" {
+			dir = "mail"
+		}
+		return FileSender{Dir: dir, From: from}, nil
+	case "smtp":
+		host := os.Getenv("SMTP_HOST")
+		if host == "" {
+			return nil, errors.New("SMTP_HOST must be set to send mail over SMTP")
+		}
+		port := os.Getenv("SMTP_PORT")
+		if port == "" {
+			port = "587"
+		}
+		return SMTPSender{
+			Addr:     net.JoinHostPort(host, port),
+			Host:     host,
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     from,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown MAIL_SENDER %q", kind)
+	}
+}
+
+// SMTPSender sends mail through an SMTP server, authenticating with PLAIN
+// auth when a username is set. net/smtp upgrades to TLS when the server
+// offers STARTTLS.
+type SMTPSender struct {
+	Addr     string
+	Host     string
+	Username string
+	Password string
+	From     string
+}
+
+func (s SMTPSender) Send(ctx context.Context, msg Message) error {
+	var auth smtp.Auth
+	if s.Username != "" {
+		auth = smtp.PlainAuth("", s.Username, s.Password, s.Host)
+	}
+
+	from := s.From
+	if i := strings.LastIndex(from, "<"); i >= 0 {
+		from = strings.TrimSuffix(from[i+1:], ">")
+	}
+	return smtp.SendMail(s.Addr, auth, from, []string{msg.To}, format(s.From, msg))
+}
+
+// LogSender writes messages to the log instead of sending them, so links in
+// them can be copied during development. With Redact set the body, and the
+// secrets in it, is left out.
+type LogSender struct {
+	Redact bool
+}
+
+func (s LogSender) Send(ctx context.Context, msg Message) error {
+	if s.Redact {
+		log.Printf("Mail to %s not sent: %s", msg.To, msg.Subject)
+		return nil
+	}
+	log.Printf("Mail to %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+// FileSender writes each message as an .eml file in Dir.
+type FileSender struct {
+	Dir  string
+	From string
+}
+
+func (s FileSender) Send(ctx context.Context, msg Message) error {
+	err := os.MkdirAll(s.Dir, 0o755)
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405.000000000"), sanitize(msg.To))
+	return os.WriteFile(filepath.Join(s.Dir, name), format(s.From, msg), 0o644)
+}
+
+// headerValue drops line breaks so values can't add headers of their own.
+var headerValue = strings.NewReplacer("\r", "", "\n", "")
+
+// format renders msg as an RFC 5322 message.
+func format(from string, msg Message) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", headerValue.Replace(from))
+	fmt.Fprintf(&b, "To: %s\r\n", headerValue.Replace(msg.To))
+	fmt.Fprintf(&b, "Subject: %s\r\n", headerValue.Replace(msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+func sanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '-' {
+			return r
+		}
+		return '_'
+	}, s)
+}
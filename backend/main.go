@@ -1,15 +1,20 @@
 package main
 
 import (
+	"database/sql"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	_ "github.com/jackc/pgx/v5/stdlib"
 
 	"github.com/Jayant-Verma/rssagg/internal/database"
+	"github.com/Jayant-Verma/rssagg/internal/mailer"
 	"github.com/go-chi/chi"
 	"github.com/go-chi/cors"
 	"github.com/joho/godotenv"
@@ -19,7 +24,21 @@ import (
 )
 
 type apiConfig struct {
-	DB *database.Queries
+	DB   *database.Queries
+	Conn *sql.DB
+	// AccessTokenTTL is how long the JWTs issued at login and refresh last;
+	// RefreshTokenTTL is how long a session may go without a refresh.
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	Cookies         cookieConfig
+	// Mailer sends password reset and verification emails, with links to
+	// pages under AppURL, the address of the frontend.
+	Mailer                   mailer.Sender
+	AppURL                   string
+	RequireEmailVerification bool
+	// TrustedProxies are the reverse proxies whose X-Forwarded-For and
+	// X-Real-IP headers are believed.
+	TrustedProxies []*net.IPNet
 }
 
 func main() {
@@ -35,6 +54,37 @@ func main() {
 		log.Fatal("DB_URL environment variable not set")
 	}
 
+	// Posts are kept forever unless POST_RETENTION_DAYS is set.
+	var postRetention time.Duration
+	if retentionString := os.Getenv("POST_RETENTION_DAYS"); retentionString != "" {
+		retentionDays, err := strconv.Atoi(retentionString)
+		if err != nil || retentionDays < 0 {
+			log.Fatal("POST_RETENTION_DAYS must be a non-negative number of days")
+		}
+		postRetention = time.Duration(retentionDays) * 24 * time.Hour
+	}
+
+	accessTokenTTL := durationFromEnv("ACCESS_TOKEN_TTL_MINUTES", time.Minute, 15*time.Minute)
+	refreshTokenTTL := durationFromEnv("REFRESH_TOKEN_TTL_DAYS", 24*time.Hour, 30*24*time.Hour)
+	cookies := cookieConfig{
+		Secure: os.Getenv("COOKIE_SECURE") == "true",
+	}
+
+	mailSender, err := mailer.FromEnv()
+	if err != nil {
+		log.Fatal("Failed to set up mail: ", err)
+	}
+	appURL := strings.TrimSuffix(os.Getenv("APP_URL"), "/")
+	if appURL == "" {
+		appURL = "http://localhost:3000"
+	}
+	// Browsers send the session cookies along with cross-origin requests, so
+	// only the frontend may make them.
+	allowedOrigins := listFromEnv("CORS_ALLOWED_ORIGINS")
+	if len(allowedOrigins) == 0 {
+		allowedOrigins = []string{appURL}
+	}
+
 	config, err := pgx.ParseConfig(dbURL)
 	if err != nil {
 		log.Fatal("Failed to parse DB_URL:", err)
@@ -51,16 +101,27 @@ func main() {
 
 	db := database.New(conn)
 	apiCfg := apiConfig{
-		DB: db,
+		DB:              db,
+		Conn:            conn,
+		AccessTokenTTL:  accessTokenTTL,
+		RefreshTokenTTL: refreshTokenTTL,
+		Cookies:         cookies,
+
+		Mailer:                   mailSender,
+		AppURL:                   appURL,
+		RequireEmailVerification: os.Getenv("REQUIRE_EMAIL_VERIFICATION") == "true",
+		TrustedProxies:           proxiesFromEnv("TRUSTED_PROXIES"),
 	}
 
-	go startScrapping(db, 10, time.Minute)
+	go startScrapping(conn, db, 10, time.Minute, postRetention)
+	go startCleanup(db, time.Hour, 24*time.Hour, postRetention)
+	go apiCfg.resumeOPMLImports()
 
 	router := chi.NewRouter()
 
 	router.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   []string{"https://*", "http://*"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedOrigins:   allowedOrigins,
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"*"},
 		ExposedHeaders:   []string{"Link"},
 		AllowCredentials: true,
@@ -72,14 +133,82 @@ func main() {
 	v1Router.Get("/err", handlerErr)
 	v1Router.Post("/auth/login", apiCfg.handlerLoginUser)
 	v1Router.Post("/auth/register", apiCfg.handlerRegisterUser)
+	v1Router.Post("/auth/refresh", apiCfg.handlerRefreshToken)
+	v1Router.Post("/auth/logout", apiCfg.middlewareAuth(apiCfg.handlerLogout))
+	v1Router.Post("/auth/forgot", apiCfg.handlerForgotPassword)
+	v1Router.Post("/auth/reset", apiCfg.handlerResetPassword)
+	v1Router.Post("/auth/verify", apiCfg.handlerVerifyEmail)
+	v1Router.Post("/auth/verify/resend", apiCfg.middlewareLogin(apiCfg.handlerResendVerification))
+	v1Router.Get("/sessions", apiCfg.middlewareLogin(apiCfg.handlerGetSessions))
+	v1Router.Delete("/sessions", apiCfg.middlewareLogin(apiCfg.handlerRevokeSessions))
+	v1Router.Delete("/sessions/{sessionID}", apiCfg.middlewareLogin(apiCfg.handlerRevokeSession))
 	v1Router.Post("/user", apiCfg.handlerCreateUser)
 	v1Router.Get("/user/me", apiCfg.middlewareAuth(apiCfg.handlerGetUser))
-	v1Router.Post("/feeds", apiCfg.middlewareAuth(apiCfg.handlerCreateFeed))
+	v1Router.Patch("/user/me", apiCfg.middlewareLogin(apiCfg.handlerUpdateUser))
+	v1Router.Delete("/user/me", apiCfg.middlewareLogin(apiCfg.handlerDeleteUser))
+	v1Router.Post("/user/me/deletion", apiCfg.middlewareLogin(apiCfg.handlerRequestAccountDeletion))
+	v1Router.Put("/user/password", apiCfg.middlewareLogin(apiCfg.handlerChangePassword))
+	v1Router.Get("/user/export", apiCfg.middlewareLogin(apiCfg.handlerExportAccount))
+	v1Router.Post("/user/api_key", apiCfg.middlewareLogin(apiCfg.handlerRotateAPIKey))
+	v1Router.Delete("/user/api_key", apiCfg.middlewareLogin(apiCfg.handlerDeleteAPIKey))
+	v1Router.Post("/user/feed_token", apiCfg.middlewareLogin(apiCfg.handlerRotateFeedToken))
+	v1Router.Post("/feeds", apiCfg.middlewareScoped(scopeFeedsManage, apiCfg.requireVerifiedEmail(apiCfg.handlerCreateFeed)))
 	v1Router.Get("/feeds", apiCfg.handlerGetFeeds)
-	v1Router.Post("/feed_follows", apiCfg.middlewareAuth(apiCfg.handlerCreateFeedFollow))
-	v1Router.Get("/feed_follows", apiCfg.middlewareAuth(apiCfg.handlerGetFeedFollows))
-	v1Router.Delete("/feed_follows/{feedFollowID}", apiCfg.middlewareAuth(apiCfg.handlerDeleteFeedFollow))
-	v1Router.Get("/posts", apiCfg.middlewareAuth(apiCfg.handlerGetPostsForUser))
+	v1Router.Post("/feeds/preview", apiCfg.middlewareScoped(scopeFeedsManage, apiCfg.handlerPreviewFeed))
+	v1Router.Put("/feeds/{feedID}", apiCfg.middlewareScoped(scopeFeedsManage, apiCfg.handlerUpdateFeed))
+	v1Router.Delete("/feeds/{feedID}", apiCfg.middlewareScoped(scopeFeedsManage, apiCfg.handlerDeleteFeed))
+	v1Router.Delete("/feeds/{feedID}/follow", apiCfg.middlewareScoped(scopeFollowsManage, apiCfg.handlerUnfollowFeed))
+	v1Router.Post("/feed_follows", apiCfg.middlewareScoped(scopeFollowsManage, apiCfg.requireVerifiedEmail(apiCfg.handlerCreateFeedFollow)))
+	v1Router.Get("/feed_follows", apiCfg.middlewareScoped(scopePostsRead, apiCfg.handlerGetFeedFollows))
+	v1Router.Patch("/feed_follows/{feedFollowID}", apiCfg.middlewareScoped(scopeFollowsManage, apiCfg.handlerUpdateFeedFollow))
+	v1Router.Delete("/feed_follows/{feedFollowID}", apiCfg.middlewareScoped(scopeFollowsManage, apiCfg.handlerDeleteFeedFollow))
+	v1Router.Put("/feed_follows/{feedFollowID}/folder", apiCfg.middlewareScoped(scopeFollowsManage, apiCfg.handlerSetFeedFollowFolder))
+	v1Router.Post("/folders", apiCfg.middlewareScoped(scopeFollowsManage, apiCfg.handlerCreateFolder))
+	v1Router.Get("/folders", apiCfg.middlewareScoped(scopePostsRead, apiCfg.handlerGetFolders))
+	v1Router.Put("/folders/{folderID}", apiCfg.middlewareScoped(scopeFollowsManage, apiCfg.handlerUpdateFolder))
+	v1Router.Delete("/folders/{folderID}", apiCfg.middlewareScoped(scopeFollowsManage, apiCfg.handlerDeleteFolder))
+	v1Router.Get("/posts", apiCfg.middlewareScoped(scopePostsRead, apiCfg.handlerGetPostsForUser))
+	v1Router.Get("/posts/search", apiCfg.middlewareScoped(scopePostsRead, apiCfg.handlerSearchPosts))
+	v1Router.Post("/posts/read", apiCfg.middlewareScoped(scopePostsWrite, apiCfg.handlerMarkPostsRead))
+	v1Router.Post("/posts/{postID}/read", apiCfg.middlewareScoped(scopePostsWrite, apiCfg.handlerMarkPostRead))
+	v1Router.Delete("/posts/{postID}/read", apiCfg.middlewareScoped(scopePostsWrite, apiCfg.handlerMarkPostUnread))
+	v1Router.Post("/posts/{postID}/star", apiCfg.middlewareScoped(scopePostsWrite, apiCfg.handlerStarPost))
+	v1Router.Delete("/posts/{postID}/star", apiCfg.middlewareScoped(scopePostsWrite, apiCfg.handlerUnstarPost))
+	v1Router.Get("/posts/{postID}/tags", apiCfg.middlewareScoped(scopePostsRead, apiCfg.handlerGetPostTags))
+	v1Router.Post("/posts/{postID}/tags", apiCfg.middlewareScoped(scopePostsWrite, apiCfg.handlerAddPostTag))
+	v1Router.Delete("/posts/{postID}/tags/{tagID}", apiCfg.middlewareScoped(scopePostsWrite, apiCfg.handlerRemovePostTag))
+	v1Router.Get("/tags", apiCfg.middlewareScoped(scopePostsRead, apiCfg.handlerGetTags))
+	v1Router.Put("/tags/{tagID}", apiCfg.middlewareScoped(scopePostsWrite, apiCfg.handlerRenameTag))
+	v1Router.Delete("/tags/{tagID}", apiCfg.middlewareScoped(scopePostsWrite, apiCfg.handlerDeleteTag))
+	v1Router.Get("/posts/{postID}/annotations", apiCfg.middlewareScoped(scopePostsRead, apiCfg.handlerGetPostAnnotations))
+	v1Router.Put("/posts/{postID}/note", apiCfg.middlewareScoped(scopePostsWrite, apiCfg.handlerSetPostNote))
+	v1Router.Delete("/posts/{postID}/note", apiCfg.middlewareScoped(scopePostsWrite, apiCfg.handlerDeletePostNote))
+	v1Router.Post("/posts/{postID}/highlights", apiCfg.middlewareScoped(scopePostsWrite, apiCfg.handlerCreatePostHighlight))
+	v1Router.Patch("/highlights/{highlightID}", apiCfg.middlewareScoped(scopePostsWrite, apiCfg.handlerUpdatePostHighlight))
+	v1Router.Delete("/highlights/{highlightID}", apiCfg.middlewareScoped(scopePostsWrite, apiCfg.handlerDeletePostHighlight))
+	v1Router.Get("/annotations", apiCfg.middlewareScoped(scopePostsRead, apiCfg.handlerGetAnnotations))
+	v1Router.Get("/annotations/export", apiCfg.middlewareScoped(scopePostsRead, apiCfg.handlerExportAnnotations))
+	v1Router.Post("/saved_searches", apiCfg.middlewareScoped(scopePostsWrite, apiCfg.handlerCreateSavedSearch))
+	v1Router.Get("/saved_searches", apiCfg.middlewareScoped(scopePostsRead, apiCfg.handlerGetSavedSearches))
+	v1Router.Put("/saved_searches/{savedSearchID}", apiCfg.middlewareScoped(scopePostsWrite, apiCfg.handlerUpdateSavedSearch))
+	v1Router.Delete("/saved_searches/{savedSearchID}", apiCfg.middlewareScoped(scopePostsWrite, apiCfg.handlerDeleteSavedSearch))
+	v1Router.Get("/saved_searches/{savedSearchID}/posts", apiCfg.middlewareScoped(scopePostsRead, apiCfg.handlerGetSavedSearchPosts))
+	v1Router.Post("/filter_rules", apiCfg.middlewareScoped(scopePostsWrite, apiCfg.handlerCreateFilterRule))
+	v1Router.Get("/filter_rules", apiCfg.middlewareScoped(scopePostsRead, apiCfg.handlerGetFilterRules))
+	v1Router.Put("/filter_rules/{filterRuleID}", apiCfg.middlewareScoped(scopePostsWrite, apiCfg.handlerUpdateFilterRule))
+	v1Router.Delete("/filter_rules/{filterRuleID}", apiCfg.middlewareScoped(scopePostsWrite, apiCfg.handlerDeleteFilterRule))
+	v1Router.Post("/filter_rules/{filterRuleID}/apply", apiCfg.middlewareScoped(scopePostsWrite, apiCfg.handlerApplyFilterRule))
+	v1Router.Get("/opml/export", apiCfg.middlewareScoped(scopePostsRead, apiCfg.handlerExportOPML))
+	v1Router.Post("/opml/import", apiCfg.middlewareScoped(scopeFeedsManage, apiCfg.requireVerifiedEmail(apiCfg.handlerImportOPML)))
+	v1Router.Get("/opml/imports/{importID}", apiCfg.middlewareScoped(scopeFeedsManage, apiCfg.handlerGetOPMLImport))
+	v1Router.Post("/tokens", apiCfg.middlewareLogin(apiCfg.handlerCreatePersonalAccessToken))
+	v1Router.Get("/tokens", apiCfg.middlewareLogin(apiCfg.handlerGetPersonalAccessTokens))
+	v1Router.Delete("/tokens/{tokenID}", apiCfg.middlewareLogin(apiCfg.handlerRevokePersonalAccessToken))
+	v1Router.Get("/counts", apiCfg.middlewareScoped(scopePostsRead, apiCfg.handlerGetUnreadCounts))
+	v1Router.Get("/output/{feedToken}/timeline", apiCfg.middlewareFeedToken(apiCfg.handlerOutputTimeline))
+	v1Router.Get("/output/{feedToken}/starred", apiCfg.middlewareFeedToken(apiCfg.handlerOutputStarred))
+	v1Router.Get("/output/{feedToken}/folders/{folderID}", apiCfg.middlewareFeedToken(apiCfg.handlerOutputFolder))
+	v1Router.Get("/output/{feedToken}/tags/{tag}", apiCfg.middlewareFeedToken(apiCfg.handlerOutputTag))
 
 	router.Mount("/v1", v1Router)
 
@@ -94,3 +223,52 @@ func main() {
 		log.Fatal(err)
 	}
 }
+
+// durationFromEnv reads a positive whole number of units from the
+// environment, falling back to fallback when the variable is not set.
+func durationFromEnv(key string, unit time.Duration, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		log.Fatalf("%s must be a positive number", key)
+	}
+	return time.Duration(n) * unit
+}
+
+// listFromEnv reads a comma-separated list from the environment, skipping
+// empty entries.
+func listFromEnv(key string) []string {
+	list := []string{}
+	for _, entry := range strings.Split(os.Getenv(key), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry != "" {
+			list = append(list, entry)
+		}
+	}
+	return list
+}
+
+// proxiesFromEnv reads a comma-separated list of IP addresses and CIDR
+// ranges from the environment.
+func proxiesFromEnv(key string) []*net.IPNet {
+	proxies := []*net.IPNet{}
+	for _, entry := range listFromEnv(key) {
+		// A bare address is a range of one.
+		if !strings.Contains(entry, "/") {
+			if strings.Contains(entry, ":") {
+				entry += "/128"
+			} else {
+				entry += "/32"
+			}
+		}
+		_, proxy, err := net.ParseCIDR(entry)
+		if err != nil {
+			log.Fatalf("%s: invalid range %q", key, entry)
+		}
+		proxies = append(proxies, proxy)
+	}
+	return proxies
+}
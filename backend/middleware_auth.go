@@ -1,12 +1,20 @@
 package main
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"os"
+	"slices"
 	"strings"
+	"time"
 
+	"github.com/Jayant-Verma/rssagg/internal/auth"
 	"github.com/Jayant-Verma/rssagg/internal/database"
+	"github.com/go-chi/chi"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"github.com/joho/godotenv"
@@ -25,63 +33,310 @@ func init() {
 	jwtSecret = []byte(os.Getenv("JWT_SECRET"))
 }
 
-func (apiCfg *apiConfig) middlewareAuth(handler authHandler) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		// Get Authorization header
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			respondWithError(w, http.StatusUnauthorized, "Missing Authorization header")
-			return
+const (
+	authMethodJWT    = "jwt"
+	authMethodCookie = "cookie"
+	authMethodAPIKey = "api_key"
+	authMethodToken  = "token"
+)
+
+// personalAccessTokenPrefix tells personal access tokens apart from JWTs,
+// since both are sent as bearer tokens.
+const personalAccessTokenPrefix = "rssagg_pat_"
+
+// authInfo describes who made a request and how they proved it. Scopes are
+// only set for personal access tokens; the other methods allow everything.
+// SessionID is set for access tokens issued at login.
+type authInfo struct {
+	User      database.User
+	Method    string
+	Scopes    []string
+	SessionID uuid.NullUUID
+}
+
+func (info authInfo) hasScope(scope string) bool {
+	if info.Method != authMethodToken {
+		return true
+	}
+	if scope == "" {
+		return false
+	}
+	for _, granted := range info.Scopes {
+		if granted == scope || slices.Contains(impliedScopes[granted], scope) {
+			return true
 		}
+	}
+	return false
+}
 
-		// Extract token (expecting "Bearer <token>")
-		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-		if tokenString == authHeader {
-			respondWithError(w, http.StatusUnauthorized, "Invalid token format")
-			return
+type authContextKey struct{}
+
+// authFromContext returns the authInfo middlewareAuth stored for the request.
+func authFromContext(ctx context.Context) (authInfo, bool) {
+	info, ok := ctx.Value(authContextKey{}).(authInfo)
+	return info, ok
+}
+
+// authError is a failed authentication, carrying the HTTP status it should
+// be reported with.
+type authError struct {
+	Code    int
+	Message string
+}
+
+func (e *authError) Error() string {
+	return e.Message
+}
+
+// middlewareAuth only lets in requests made with the user's own credentials.
+// Routes that personal access tokens may use go through middlewareScoped.
+func (apiCfg *apiConfig) middlewareAuth(handler authHandler) http.HandlerFunc {
+	return apiCfg.middlewareScoped("", handler)
+}
+
+// middlewareScoped is middlewareAuth for routes that also accept personal
+// access tokens carrying scope.
+func (apiCfg *apiConfig) middlewareScoped(scope string, handler authHandler) http.HandlerFunc {
+	return apiCfg.middlewareAuthorized(handler, func(info authInfo) string {
+		if info.hasScope(scope) {
+			return ""
 		}
+		if scope == "" {
+			return "Personal access tokens can't be used for this endpoint"
+		}
+		return fmt.Sprintf("Token is missing the %s scope", scope)
+	})
+}
 
-		// Validate JWT token
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-			return jwtSecret, nil
-		})
+// middlewareLogin only lets in requests made from a login session. It guards
+// the routes that manage credentials and the account itself, so a leaked API
+// key or access token can't be turned into control of the account.
+func (apiCfg *apiConfig) middlewareLogin(handler authHandler) http.HandlerFunc {
+	return apiCfg.middlewareAuthorized(handler, func(info authInfo) string {
+		if info.Method == authMethodJWT || info.Method == authMethodCookie {
+			return ""
+		}
+		return "This endpoint needs a login session, API keys and personal access tokens can't be used"
+	})
+}
 
-		// Check if token is valid
-		if err != nil || !token.Valid {
-			respondWithError(w, http.StatusUnauthorized, "Invalid or expired token")
+// middlewareAuthorized authenticates the request and passes it on when
+// forbidden finds nothing wrong with the credentials; otherwise the message
+// forbidden returns is sent with a 403.
+func (apiCfg *apiConfig) middlewareAuthorized(handler authHandler, forbidden func(authInfo) string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		info, err := apiCfg.authenticate(r)
+		var authErr *authError
+		if errors.As(err, &authErr) {
+			respondWithError(w, authErr.Code, authErr.Message)
 			return
 		}
-
-		// Extract user ID from token claims
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			respondWithError(w, http.StatusUnauthorized, "Invalid token claims")
+		if err != nil {
+			respondWithError(w, 500, fmt.Sprintf("Couldn't authenticate request: %v", err))
 			return
 		}
-
-		userIDStr, ok := claims["user_id"].(string)
-		if !ok {
-			respondWithError(w, http.StatusUnauthorized, "Invalid user ID in token")
+		if msg := forbidden(info); msg != "" {
+			respondWithError(w, http.StatusForbidden, msg)
 			return
 		}
 
-		// Fetch user from database
-		userID, err := uuid.Parse(userIDStr)
+		// Pass user to the handler
+		r = r.WithContext(context.WithValue(r.Context(), authContextKey{}, info))
+		handler(w, r, info.User)
+	}
+}
+
+// authenticate identifies the user from the Authorization header, which
+// holds "Bearer <jwt>", "Bearer <personal access token>" or "ApiKey <key>".
+// Without the header, the JWT in the login cookie is used instead.
+func (apiCfg *apiConfig) authenticate(r *http.Request) (authInfo, error) {
+	authHeader := r.Header.Get("Authorization")
+	if authHeader == "" {
+		return apiCfg.authenticateCookie(r)
+	}
+
+	scheme, _, _ := strings.Cut(authHeader, " ")
+	switch scheme {
+	case "Bearer":
+		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+		if strings.HasPrefix(tokenString, personalAccessTokenPrefix) {
+			return apiCfg.authenticatePersonalAccessToken(r.Context(), tokenString)
+		}
+		return apiCfg.authenticateJWT(r, tokenString)
+	case "ApiKey":
+		apiKey, err := auth.GetAPIKey(r.Header)
 		if err != nil {
-			respondWithError(w, http.StatusUnauthorized, "Invalid user ID format")
-			return
+			return authInfo{}, &authError{Code: http.StatusUnauthorized, Message: "Invalid API key format"}
+		}
+		return apiCfg.authenticateAPIKey(r.Context(), apiKey)
+	default:
+		return authInfo{}, &authError{Code: http.StatusUnauthorized, Message: "Invalid token format"}
+	}
+}
+
+// authenticateCookie authenticates browsers by the login cookie. Browsers
+// attach cookies to requests other sites trigger too, so state-changing
+// requests must also carry the session's CSRF token.
+func (apiCfg *apiConfig) authenticateCookie(r *http.Request) (authInfo, error) {
+	cookie, err := r.Cookie(tokenCookieName)
+	if err != nil || cookie.Value == "" {
+		return authInfo{}, &authError{Code: http.StatusUnauthorized, Message: "Missing Authorization header"}
+	}
+
+	info, err := apiCfg.authenticateJWT(r, cookie.Value)
+	if err != nil {
+		return authInfo{}, err
+	}
+	if !isSafeMethod(r.Method) && !validCSRFToken(r, info.SessionID.UUID) {
+		return authInfo{}, &authError{Code: http.StatusForbidden, Message: "Missing or invalid CSRF token"}
+	}
+
+	info.Method = authMethodCookie
+	return info, nil
+}
+
+func (apiCfg *apiConfig) authenticateJWT(r *http.Request, tokenString string) (authInfo, error) {
+	ctx := r.Context()
+	// Validate JWT token
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
+		return jwtSecret, nil
+	})
 
-		user, err := apiCfg.DB.GetUserByID(r.Context(), userID)
+	// Check if token is valid
+	if err != nil || !token.Valid {
+		return authInfo{}, &authError{Code: http.StatusUnauthorized, Message: "Invalid or expired token"}
+	}
+
+	// Extract user ID from token claims
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return authInfo{}, &authError{Code: http.StatusUnauthorized, Message: "Invalid token claims"}
+	}
+
+	userIDStr, ok := claims["user_id"].(string)
+	if !ok {
+		return authInfo{}, &authError{Code: http.StatusUnauthorized, Message: "Invalid user ID in token"}
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		return authInfo{}, &authError{Code: http.StatusUnauthorized, Message: "Invalid user ID format"}
+	}
+
+	// Tokens name their login session, which may have been revoked since.
+	sessionIDStr, _ := claims["sid"].(string)
+	sessionID, err := uuid.Parse(sessionIDStr)
+	if err != nil {
+		return authInfo{}, &authError{Code: http.StatusUnauthorized, Message: "Invalid or expired token"}
+	}
+	session, err := apiCfg.DB.GetSessionByID(ctx, sessionID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && session.UserID != userID) {
+		return authInfo{}, &authError{Code: http.StatusUnauthorized, Message: "Invalid or expired token"}
+	}
+	if err != nil {
+		return authInfo{}, err
+	}
+	if session.RevokedAt.Valid {
+		return authInfo{}, &authError{Code: http.StatusUnauthorized, Message: "Session has been revoked"}
+	}
+
+	// Fetch user from database
+	user, err := apiCfg.DB.GetUserByID(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return authInfo{}, &authError{Code: http.StatusNotFound, Message: "User not found"}
+	}
+	if err != nil {
+		return authInfo{}, err
+	}
+
+	// Like personal access tokens, sessions are touched at most once a minute.
+	err = apiCfg.DB.TouchSession(ctx, database.TouchSessionParams{
+		ID: session.ID,
+		Ip: apiCfg.clientIP(r),
+	})
+	if err != nil {
+		log.Printf("Failed to record use of session %v: %v", session.ID, err)
+	}
+
+	return authInfo{
+		User:      user,
+		Method:    authMethodJWT,
+		SessionID: uuid.NullUUID{UUID: session.ID, Valid: true},
+	}, nil
+}
+
+func (apiCfg *apiConfig) authenticateAPIKey(ctx context.Context, apiKey string) (authInfo, error) {
+	user, err := apiCfg.DB.GetUserByAPIKeyHash(ctx, sql.NullString{String: auth.HashToken(apiKey), Valid: true})
+	if errors.Is(err, sql.ErrNoRows) {
+		return authInfo{}, &authError{Code: http.StatusUnauthorized, Message: "Invalid API key"}
+	}
+	if err != nil {
+		return authInfo{}, err
+	}
+
+	// API keys are for scripts reading and organizing the user's feeds;
+	// admin work needs a login or a token with the admin scope.
+	user.IsAdmin = false
+
+	return authInfo{User: user, Method: authMethodAPIKey}, nil
+}
+
+func (apiCfg *apiConfig) authenticatePersonalAccessToken(ctx context.Context, tokenString string) (authInfo, error) {
+	token, err := apiCfg.DB.GetPersonalAccessTokenByHash(ctx, auth.HashToken(tokenString))
+	if errors.Is(err, sql.ErrNoRows) {
+		return authInfo{}, &authError{Code: http.StatusUnauthorized, Message: "Invalid or expired token"}
+	}
+	if err != nil {
+		return authInfo{}, err
+	}
+	if token.RevokedAt.Valid {
+		return authInfo{}, &authError{Code: http.StatusUnauthorized, Message: "Token has been revoked"}
+	}
+	if token.ExpiresAt.Valid && !token.ExpiresAt.Time.After(time.Now().UTC()) {
+		return authInfo{}, &authError{Code: http.StatusUnauthorized, Message: "Invalid or expired token"}
+	}
+
+	user, err := apiCfg.DB.GetUserByID(ctx, token.UserID)
+	if err != nil {
+		return authInfo{}, err
+	}
+
+	scopes := strings.Fields(token.Scopes)
+	// Handlers check IsAdmin directly, so a token without the admin scope
+	// acts as a regular user even when its owner is an admin.
+	if !slices.Contains(scopes, scopeAdmin) {
+		user.IsAdmin = false
+	}
+
+	// Last use is recorded at most once a minute to spare a write per request.
+	err = apiCfg.DB.TouchPersonalAccessToken(ctx, token.ID)
+	if err != nil {
+		log.Printf("Failed to record use of token %v: %v", token.ID, err)
+	}
+
+	return authInfo{User: user, Method: authMethodToken, Scopes: scopes}, nil
+}
+
+// middlewareFeedToken authenticates the output feed routes by the secret
+// token in their URL, so feed readers can fetch them without a JWT.
+func (apiCfg *apiConfig) middlewareFeedToken(handler authHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, err := apiCfg.DB.GetUserByFeedTokenHash(r.Context(), sql.NullString{
+			String: auth.HashToken(chi.URLParam(r, "feedToken")),
+			Valid:  true,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Feed not found")
+			return
+		}
 		if err != nil {
-			respondWithError(w, http.StatusNotFound, "User not found")
+			respondWithError(w, 500, fmt.Sprintf("Couldn't get user: %v", err))
 			return
 		}
 
-		// Pass user to the handler
 		handler(w, r, user)
 	}
 }
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Jayant-Verma/rssagg/internal/auth"
+	"github.com/Jayant-Verma/rssagg/internal/database"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestAuthenticateJWT(t *testing.T) {
+	tests := []struct {
+		name     string
+		token    func(t *testing.T, session database.Session) string
+		revoke   bool
+		wantCode int
+	}{
+		{
+			name:  "token of an active session",
+			token: sessionJWT,
+		},
+		{
+			name:     "token of a revoked session",
+			token:    sessionJWT,
+			revoke:   true,
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			// Tokens issued before sessions existed carry no sid claim.
+			name: "token without a session",
+			token: func(t *testing.T, session database.Session) string {
+				token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+					"user_id": session.UserID.String(),
+					"exp":     time.Now().Add(time.Hour).Unix(),
+				}).SignedString(jwtSecret)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return token
+			},
+			wantCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newFakeStore()
+			apiCfg := newTestAPIConfig(t, store)
+			user, session := addTestSession(store)
+			if tt.revoke {
+				session.RevokedAt = sql.NullTime{Time: time.Now().UTC(), Valid: true}
+				store.sessions[session.ID] = session
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/users", nil)
+			info, err := apiCfg.authenticateJWT(req, tt.token(t, session))
+
+			if tt.wantCode == 0 {
+				if err != nil {
+					t.Fatalf("authenticateJWT() error = %v", err)
+				}
+				if info.User.ID != user.ID || info.SessionID.UUID != session.ID {
+					t.Errorf("authenticated user %v session %v, want user %v session %v", info.User.ID, info.SessionID.UUID, user.ID, session.ID)
+				}
+				return
+			}
+			var authErr *authError
+			if !errors.As(err, &authErr) || authErr.Code != tt.wantCode {
+				t.Fatalf("authenticateJWT() error = %v, want auth error with code %d", err, tt.wantCode)
+			}
+		})
+	}
+}
+
+func sessionJWT(t *testing.T, session database.Session) string {
+	token, err := auth.GetJWT(session.UserID, session.ID, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestMiddlewareLogin(t *testing.T) {
+	tests := []struct {
+		name      string
+		header    func(t *testing.T, session database.Session) string
+		wantCode  int
+		wantAdmin bool
+	}{
+		{
+			name: "login session",
+			header: func(t *testing.T, session database.Session) string {
+				return "Bearer " + sessionJWT(t, session)
+			},
+			wantCode:  http.StatusOK,
+			wantAdmin: true,
+		},
+		{
+			name: "API key",
+			header: func(t *testing.T, session database.Session) string {
+				return "ApiKey test-api-key"
+			},
+			wantCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newFakeStore()
+			apiCfg := newTestAPIConfig(t, store)
+			user, session := addTestSession(store)
+			user.IsAdmin = true
+			user.ApiKeyHash = sql.NullString{String: auth.HashToken("test-api-key"), Valid: true}
+			store.users[user.ID] = user
+
+			var gotAdmin bool
+			handler := apiCfg.middlewareLogin(func(w http.ResponseWriter, r *http.Request, user database.User) {
+				gotAdmin = user.IsAdmin
+				w.WriteHeader(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodPut, "/v1/user/password", nil)
+			req.Header.Set("Authorization", tt.header(t, session))
+			rec := httptest.NewRecorder()
+			handler(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantCode, rec.Body.String())
+			}
+			if gotAdmin != tt.wantAdmin {
+				t.Errorf("handler saw IsAdmin = %v, want %v", gotAdmin, tt.wantAdmin)
+			}
+		})
+	}
+}
+
+func TestAuthenticateAPIKeyDropsAdmin(t *testing.T) {
+	store := newFakeStore()
+	apiCfg := newTestAPIConfig(t, store)
+	user, _ := addTestSession(store)
+	user.IsAdmin = true
+	user.ApiKeyHash = sql.NullString{String: auth.HashToken("test-api-key"), Valid: true}
+	store.users[user.ID] = user
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/posts", nil)
+	info, err := apiCfg.authenticateAPIKey(req.Context(), "test-api-key")
+	if err != nil {
+		t.Fatalf("authenticateAPIKey() error = %v", err)
+	}
+	if info.User.ID != user.ID || info.User.IsAdmin {
+		t.Errorf("authenticated user %v with IsAdmin = %v, want user %v without admin rights", info.User.ID, info.User.IsAdmin, user.ID)
+	}
+}
+
+func TestHasScope(t *testing.T) {
+	tests := []struct {
+		name   string
+		info   authInfo
+		scope  string
+		wanted bool
+	}{
+		{name: "login session", info: authInfo{Method: authMethodJWT}, scope: "", wanted: true},
+		{name: "token on a login-only route", info: authInfo{Method: authMethodToken, Scopes: []string{scopePostsRead}}, scope: "", wanted: false},
+		{name: "token with the scope", info: authInfo{Method: authMethodToken, Scopes: []string{scopePostsWrite}}, scope: scopePostsWrite, wanted: true},
+		{name: "read token on a write route", info: authInfo{Method: authMethodToken, Scopes: []string{scopePostsRead}}, scope: scopePostsWrite, wanted: false},
+		{name: "admin token on a feed route", info: authInfo{Method: authMethodToken, Scopes: []string{scopeAdmin}}, scope: scopeFeedsManage, wanted: true},
+		{name: "admin token on a post route", info: authInfo{Method: authMethodToken, Scopes: []string{scopeAdmin}}, scope: scopePostsRead, wanted: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.info.hasScope(tt.scope); got != tt.wanted {
+				t.Errorf("hasScope(%q) = %v, want %v", tt.scope, got, tt.wanted)
+			}
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"database/sql"
+	"strings"
 	"time"
 
 	"github.com/Jayant-Verma/rssagg/internal/database"
@@ -8,25 +10,103 @@ import (
 )
 
 type User struct {
-	ID        string `json:"id"`
-	CreatedAt string `json:"created_at"`
-	UpdatedAt string `json:"updated_at"`
-	Name      string `json:"name"`
-	Email     string `json:"email"`
-	APIKey    string `json:"api_key"`
+	ID            string `json:"id"`
+	CreatedAt     string `json:"created_at"`
+	UpdatedAt     string `json:"updated_at"`
+	Name          string `json:"name"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	HasAPIKey     bool   `json:"has_api_key"`
+	IsAdmin       bool   `json:"is_admin"`
+	HasFeedToken  bool   `json:"has_feed_token"`
 }
 
 func databaseUserToUser(dbUser database.User) User {
 	return User{
-		ID:        dbUser.ID.String(),
-		CreatedAt: dbUser.CreatedAt.Format(time.RFC3339),
-		UpdatedAt: dbUser.UpdatedAt.Format(time.RFC3339),
-		Name:      dbUser.Name,
-		Email:     dbUser.Email,
-		APIKey:    dbUser.ApiKey,
+		ID:            dbUser.ID.String(),
+		CreatedAt:     dbUser.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:     dbUser.UpdatedAt.Format(time.RFC3339),
+		Name:          dbUser.Name,
+		Email:         dbUser.Email,
+		EmailVerified: dbUser.EmailVerifiedAt.Valid,
+		HasAPIKey:     dbUser.ApiKeyHash.Valid,
+		IsAdmin:       dbUser.IsAdmin,
+		HasFeedToken:  dbUser.FeedTokenHash.Valid,
 	}
 }
 
+type PersonalAccessToken struct {
+	ID         uuid.UUID  `json:"id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+type CreatedPersonalAccessToken struct {
+	PersonalAccessToken
+	Token string `json:"token"`
+}
+
+func databasePersonalAccessTokenToPersonalAccessToken(dbToken database.PersonalAccessToken) PersonalAccessToken {
+	token := PersonalAccessToken{
+		ID:        dbToken.ID,
+		CreatedAt: dbToken.CreatedAt,
+		Name:      dbToken.Name,
+		Scopes:    strings.Fields(dbToken.Scopes),
+	}
+	if dbToken.ExpiresAt.Valid {
+		token.ExpiresAt = &dbToken.ExpiresAt.Time
+	}
+	if dbToken.LastUsedAt.Valid {
+		token.LastUsedAt = &dbToken.LastUsedAt.Time
+	}
+	if dbToken.RevokedAt.Valid {
+		token.RevokedAt = &dbToken.RevokedAt.Time
+	}
+	return token
+}
+
+func databasePersonalAccessTokensToPersonalAccessTokens(dbTokens []database.PersonalAccessToken) []PersonalAccessToken {
+	tokens := []PersonalAccessToken{}
+	for _, token := range dbTokens {
+		tokens = append(tokens, databasePersonalAccessTokenToPersonalAccessToken(token))
+	}
+	return tokens
+}
+
+type Session struct {
+	ID         uuid.UUID `json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	Current    bool      `json:"current"`
+}
+
+func databaseSessionToSession(dbSession database.Session, currentID uuid.NullUUID) Session {
+	return Session{
+		ID:         dbSession.ID,
+		CreatedAt:  dbSession.CreatedAt,
+		LastSeenAt: dbSession.LastSeenAt,
+		ExpiresAt:  dbSession.ExpiresAt,
+		UserAgent:  dbSession.UserAgent,
+		IP:         dbSession.Ip,
+		Current:    currentID.Valid && currentID.UUID == dbSession.ID,
+	}
+}
+
+func databaseSessionsToSessions(dbSessions []database.Session, currentID uuid.NullUUID) []Session {
+	sessions := []Session{}
+	for _, session := range dbSessions {
+		sessions = append(sessions, databaseSessionToSession(session, currentID))
+	}
+	return sessions
+}
+
 type Feed struct {
 	ID        uuid.UUID `json:"id"`
 	CreatedAt time.Time `json:"created_at"`
@@ -34,9 +114,20 @@ type Feed struct {
 	Name      string    `json:"name"`
 	URL       string    `json:"url"`
 	UserID    uuid.UUID `json:"user_id"`
+	Paused    bool      `json:"paused"`
+	Language  *string   `json:"language"`
+	SiteURL   *string   `json:"site_url"`
 }
 
 func databaseFeedToFeed(dbFeed database.Feed) Feed {
+	var language *string
+	if dbFeed.Language.Valid {
+		language = &dbFeed.Language.String
+	}
+	var siteURL *string
+	if dbFeed.SiteUrl.Valid {
+		siteURL = &dbFeed.SiteUrl.String
+	}
 	return Feed{
 		ID:        dbFeed.ID,
 		CreatedAt: dbFeed.CreatedAt,
@@ -44,6 +135,9 @@ func databaseFeedToFeed(dbFeed database.Feed) Feed {
 		Name:      dbFeed.Name,
 		URL:       dbFeed.Url,
 		UserID:    dbFeed.UserID,
+		Paused:    dbFeed.Paused,
+		Language:  language,
+		SiteURL:   siteURL,
 	}
 }
 
@@ -56,20 +150,40 @@ func databaseFeedsToFeeds(dbFeeds []database.Feed) []Feed {
 }
 
 type FeedFollow struct {
-	ID        uuid.UUID `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	UserID    uuid.UUID `json:"user_id"`
-	FeedID    uuid.UUID `json:"feed_id"`
+	ID             uuid.UUID  `json:"id"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	UserID         uuid.UUID  `json:"user_id"`
+	FeedID         uuid.UUID  `json:"feed_id"`
+	Title          *string    `json:"title"`
+	Priority       int32      `json:"priority"`
+	Muted          bool       `json:"muted"`
+	ShowInTimeline bool       `json:"show_in_timeline"`
+	Notifications  string     `json:"notifications"`
+	FolderID       *uuid.UUID `json:"folder_id"`
 }
 
 func databaseFeedFollowToFeedFollow(dbFeedFollow database.FeedFollow) FeedFollow {
+	var title *string
+	if dbFeedFollow.Title.Valid {
+		title = &dbFeedFollow.Title.String
+	}
+	var folderID *uuid.UUID
+	if dbFeedFollow.FolderID.Valid {
+		folderID = &dbFeedFollow.FolderID.UUID
+	}
 	return FeedFollow{
-		ID:        dbFeedFollow.ID,
-		CreatedAt: dbFeedFollow.CreatedAt,
-		UpdatedAt: dbFeedFollow.UpdatedAt,
-		UserID:    dbFeedFollow.UserID,
-		FeedID:    dbFeedFollow.FeedID,
+		ID:             dbFeedFollow.ID,
+		CreatedAt:      dbFeedFollow.CreatedAt,
+		UpdatedAt:      dbFeedFollow.UpdatedAt,
+		UserID:         dbFeedFollow.UserID,
+		FeedID:         dbFeedFollow.FeedID,
+		Title:          title,
+		Priority:       dbFeedFollow.Priority,
+		Muted:          dbFeedFollow.Muted,
+		ShowInTimeline: dbFeedFollow.ShowInTimeline,
+		Notifications:  dbFeedFollow.Notifications,
+		FolderID:       folderID,
 	}
 }
 
@@ -81,15 +195,141 @@ func databaseFeedFollowsToFeedFollows(dbFeedFollows []database.FeedFollow) []Fee
 	return feedFollows
 }
 
+// FeedFollowWithFeed is a subscription together with the feed it points at.
+// DisplayTitle is the user's custom title, falling back to the feed name.
+type FeedFollowWithFeed struct {
+	FeedFollow
+	DisplayTitle string `json:"display_title"`
+	FeedName     string `json:"feed_name"`
+	FeedURL      string `json:"feed_url"`
+	FeedPaused   bool   `json:"feed_paused"`
+}
+
+func databaseFeedFollowToFeedFollowWithFeed(dbFeedFollow database.FeedFollow, dbFeed database.Feed) FeedFollowWithFeed {
+	displayTitle := dbFeed.Name
+	if dbFeedFollow.Title.Valid {
+		displayTitle = dbFeedFollow.Title.String
+	}
+	return FeedFollowWithFeed{
+		FeedFollow:   databaseFeedFollowToFeedFollow(dbFeedFollow),
+		DisplayTitle: displayTitle,
+		FeedName:     dbFeed.Name,
+		FeedURL:      dbFeed.Url,
+		FeedPaused:   dbFeed.Paused,
+	}
+}
+
+func databaseFeedFollowRowsToFeedFollowsWithFeeds(rows []database.GetFeedFollowsWithFeedsRow) []FeedFollowWithFeed {
+	feedFollows := []FeedFollowWithFeed{}
+	for _, row := range rows {
+		feedFollows = append(feedFollows, databaseFeedFollowToFeedFollowWithFeed(
+			database.FeedFollow{
+				ID:             row.ID,
+				CreatedAt:      row.CreatedAt,
+				UpdatedAt:      row.UpdatedAt,
+				UserID:         row.UserID,
+				FeedID:         row.FeedID,
+				Title:          row.Title,
+				Priority:       row.Priority,
+				Muted:          row.Muted,
+				ShowInTimeline: row.ShowInTimeline,
+				Notifications:  row.Notifications,
+				FolderID:       row.FolderID,
+			},
+			database.Feed{
+				ID:     row.FeedID,
+				Name:   row.FeedName,
+				Url:    row.FeedUrl,
+				Paused: row.FeedPaused,
+			},
+		))
+	}
+	return feedFollows
+}
+
+type Folder struct {
+	ID        uuid.UUID  `json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	UserID    uuid.UUID  `json:"user_id"`
+	ParentID  *uuid.UUID `json:"parent_id"`
+	Name      string     `json:"name"`
+}
+
+func databaseFolderToFolder(dbFolder database.Folder) Folder {
+	var parentID *uuid.UUID
+	if dbFolder.ParentID.Valid {
+		parentID = &dbFolder.ParentID.UUID
+	}
+	return Folder{
+		ID:        dbFolder.ID,
+		CreatedAt: dbFolder.CreatedAt,
+		UpdatedAt: dbFolder.UpdatedAt,
+		UserID:    dbFolder.UserID,
+		ParentID:  parentID,
+		Name:      dbFolder.Name,
+	}
+}
+
+func databaseFoldersToFolders(dbFolders []database.Folder) []Folder {
+	folders := []Folder{}
+	for _, folder := range dbFolders {
+		folders = append(folders, databaseFolderToFolder(folder))
+	}
+	return folders
+}
+
+type SavedSearch struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	UserID    uuid.UUID `json:"user_id"`
+	Name      string    `json:"name"`
+	Query     string    `json:"query"`
+	Lang      string    `json:"lang"`
+}
+
+func databaseSavedSearchToSavedSearch(dbSavedSearch database.SavedSearch) SavedSearch {
+	return SavedSearch{
+		ID:        dbSavedSearch.ID,
+		CreatedAt: dbSavedSearch.CreatedAt,
+		UpdatedAt: dbSavedSearch.UpdatedAt,
+		UserID:    dbSavedSearch.UserID,
+		Name:      dbSavedSearch.Name,
+		Query:     dbSavedSearch.Query,
+		Lang:      dbSavedSearch.Lang,
+	}
+}
+
+func databaseSavedSearchesToSavedSearches(dbSavedSearches []database.SavedSearch) []SavedSearch {
+	savedSearches := []SavedSearch{}
+	for _, savedSearch := range dbSavedSearches {
+		savedSearches = append(savedSearches, databaseSavedSearchToSavedSearch(savedSearch))
+	}
+	return savedSearches
+}
+
 type Post struct {
-	ID          uuid.UUID `json:"id"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
-	Title       string    `json:"title"`
-	Description *string   `json:"description"`
-	PublishedAt time.Time `json:"published_at"`
-	URL         string    `json:"url"`
-	FeedID      uuid.UUID `json:"feed_id"`
+	ID          uuid.UUID  `json:"id"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	Title       string     `json:"title"`
+	Description *string    `json:"description"`
+	PublishedAt time.Time  `json:"published_at"`
+	URL         string     `json:"url"`
+	FeedID      uuid.UUID  `json:"feed_id"`
+	Content     *string    `json:"content"`
+	Author      *string    `json:"author"`
+	Categories  []string   `json:"categories"`
+	Read        bool       `json:"read"`
+	ReadAt      *time.Time `json:"read_at"`
+	Starred     bool       `json:"starred"`
+	StarredAt   *time.Time `json:"starred_at"`
+}
+
+type PostsPage struct {
+	Posts      []Post  `json:"posts"`
+	NextCursor *string `json:"next_cursor"`
 }
 
 func databasePostToPost(dbPost database.Post) Post {
@@ -97,6 +337,18 @@ func databasePostToPost(dbPost database.Post) Post {
 	if dbPost.Description.Valid {
 		description = &dbPost.Description.String
 	}
+	var content *string
+	if dbPost.Content.Valid {
+		content = &dbPost.Content.String
+	}
+	var author *string
+	if dbPost.Author.Valid {
+		author = &dbPost.Author.String
+	}
+	categories := []string{}
+	if dbPost.Categories.Valid {
+		categories = strings.Split(dbPost.Categories.String, "\n")
+	}
 	return Post{
 		ID:          dbPost.ID,
 		CreatedAt:   dbPost.CreatedAt,
@@ -106,6 +358,9 @@ func databasePostToPost(dbPost database.Post) Post {
 		PublishedAt: dbPost.PublishedAt,
 		URL:         dbPost.Url,
 		FeedID:      dbPost.FeedID,
+		Content:     content,
+		Author:      author,
+		Categories:  categories,
 	}
 }
 
@@ -116,3 +371,389 @@ func databasePostsToPosts(dbPosts []database.Post) []Post {
 	}
 	return posts
 }
+
+// databasePostWithStateToPost adds the user's read and starred state to a post.
+func databasePostWithStateToPost(dbPost database.Post, read bool, readAt sql.NullTime, starred bool, starredAt sql.NullTime) Post {
+	post := databasePostToPost(dbPost)
+	post.Read = read
+	if readAt.Valid {
+		post.ReadAt = &readAt.Time
+	}
+	post.Starred = starred
+	if starredAt.Valid {
+		post.StarredAt = &starredAt.Time
+	}
+	return post
+}
+
+func databasePostRowsToPosts(rows []database.GetPostsForUserRow) []Post {
+	posts := []Post{}
+	for _, row := range rows {
+		posts = append(posts, databasePostWithStateToPost(database.Post{
+			ID:          row.ID,
+			CreatedAt:   row.CreatedAt,
+			UpdatedAt:   row.UpdatedAt,
+			Title:       row.Title,
+			Description: row.Description,
+			PublishedAt: row.PublishedAt,
+			Url:         row.Url,
+			FeedID:      row.FeedID,
+			Content:     row.Content,
+			Author:      row.Author,
+			Categories:  row.Categories,
+		}, row.Read, row.ReadAt, row.Starred, row.StarredAt))
+	}
+	return posts
+}
+
+func databaseStarredPostRowsToPosts(rows []database.GetStarredPostsForUserRow) []Post {
+	posts := []Post{}
+	for _, row := range rows {
+		posts = append(posts, databasePostWithStateToPost(database.Post{
+			ID:          row.ID,
+			CreatedAt:   row.CreatedAt,
+			UpdatedAt:   row.UpdatedAt,
+			Title:       row.Title,
+			Description: row.Description,
+			PublishedAt: row.PublishedAt,
+			Url:         row.Url,
+			FeedID:      row.FeedID,
+			Content:     row.Content,
+			Author:      row.Author,
+			Categories:  row.Categories,
+		}, row.Read, row.ReadAt, true, row.StarredAt))
+	}
+	return posts
+}
+
+func databaseSavedSearchPostRowsToPosts(rows []database.GetSavedSearchPostsRow) []Post {
+	posts := []Post{}
+	for _, row := range rows {
+		posts = append(posts, databasePostWithStateToPost(database.Post{
+			ID:          row.ID,
+			CreatedAt:   row.CreatedAt,
+			UpdatedAt:   row.UpdatedAt,
+			Title:       row.Title,
+			Description: row.Description,
+			PublishedAt: row.PublishedAt,
+			Url:         row.Url,
+			FeedID:      row.FeedID,
+			Content:     row.Content,
+			Author:      row.Author,
+			Categories:  row.Categories,
+		}, row.Read, row.ReadAt, row.Starred, row.StarredAt))
+	}
+	return posts
+}
+
+type Tag struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Name      string    `json:"name"`
+}
+
+type TagWithCount struct {
+	Tag
+	PostCount int64 `json:"post_count"`
+}
+
+func databaseTagToTag(dbTag database.Tag) Tag {
+	return Tag{
+		ID:        dbTag.ID,
+		CreatedAt: dbTag.CreatedAt,
+		Name:      dbTag.Name,
+	}
+}
+
+func databaseTagsToTags(dbTags []database.Tag) []Tag {
+	tags := []Tag{}
+	for _, tag := range dbTags {
+		tags = append(tags, databaseTagToTag(tag))
+	}
+	return tags
+}
+
+func databaseTagRowsToTagsWithCounts(rows []database.GetTagsWithCountsRow) []TagWithCount {
+	tags := []TagWithCount{}
+	for _, row := range rows {
+		tags = append(tags, TagWithCount{
+			Tag: databaseTagToTag(database.Tag{
+				ID:        row.ID,
+				CreatedAt: row.CreatedAt,
+				UserID:    row.UserID,
+				Name:      row.Name,
+			}),
+			PostCount: row.PostCount,
+		})
+	}
+	return tags
+}
+
+type PostNote struct {
+	PostID    uuid.UUID `json:"post_id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Body      string    `json:"body"`
+}
+
+func databasePostNoteToPostNote(dbNote database.PostNote) PostNote {
+	return PostNote{
+		PostID:    dbNote.PostID,
+		CreatedAt: dbNote.CreatedAt,
+		UpdatedAt: dbNote.UpdatedAt,
+		Body:      dbNote.Body,
+	}
+}
+
+type PostHighlight struct {
+	ID          uuid.UUID `json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	PostID      uuid.UUID `json:"post_id"`
+	Quote       string    `json:"quote"`
+	StartOffset *int32    `json:"start_offset"`
+	EndOffset   *int32    `json:"end_offset"`
+	Comment     *string   `json:"comment"`
+}
+
+func databasePostHighlightToPostHighlight(dbHighlight database.PostHighlight) PostHighlight {
+	var startOffset, endOffset *int32
+	if dbHighlight.StartOffset.Valid && dbHighlight.EndOffset.Valid {
+		startOffset = &dbHighlight.StartOffset.Int32
+		endOffset = &dbHighlight.EndOffset.Int32
+	}
+	var comment *string
+	if dbHighlight.Comment.Valid {
+		comment = &dbHighlight.Comment.String
+	}
+	return PostHighlight{
+		ID:          dbHighlight.ID,
+		CreatedAt:   dbHighlight.CreatedAt,
+		UpdatedAt:   dbHighlight.UpdatedAt,
+		PostID:      dbHighlight.PostID,
+		Quote:       dbHighlight.Quote,
+		StartOffset: startOffset,
+		EndOffset:   endOffset,
+		Comment:     comment,
+	}
+}
+
+func databasePostHighlightsToPostHighlights(dbHighlights []database.PostHighlight) []PostHighlight {
+	highlights := []PostHighlight{}
+	for _, highlight := range dbHighlights {
+		highlights = append(highlights, databasePostHighlightToPostHighlight(highlight))
+	}
+	return highlights
+}
+
+type PostAnnotations struct {
+	Note       *PostNote       `json:"note"`
+	Highlights []PostHighlight `json:"highlights"`
+}
+
+// AnnotatedPost is a post along with the user's note and highlights on it,
+// as listed by GET /v1/annotations.
+type AnnotatedPost struct {
+	PostAnnotations
+	PostID          uuid.UUID `json:"post_id"`
+	Title           string    `json:"title"`
+	URL             string    `json:"url"`
+	FeedID          uuid.UUID `json:"feed_id"`
+	FeedName        string    `json:"feed_name"`
+	PublishedAt     time.Time `json:"published_at"`
+	LastAnnotatedAt time.Time `json:"last_annotated_at"`
+}
+
+type OPMLImport struct {
+	ID         uuid.UUID        `json:"id"`
+	CreatedAt  time.Time        `json:"created_at"`
+	UpdatedAt  time.Time        `json:"updated_at"`
+	Status     string           `json:"status"`
+	Error      *string          `json:"error"`
+	FinishedAt *time.Time       `json:"finished_at"`
+	Total      int32            `json:"total"`
+	Pending    int              `json:"pending"`
+	Created    int              `json:"created"`
+	Existing   int              `json:"existing"`
+	Failed     int              `json:"failed"`
+	Items      []OPMLImportItem `json:"items"`
+}
+
+type OPMLImportItem struct {
+	Title        string     `json:"title"`
+	URL          string     `json:"url"`
+	ParentFolder *string    `json:"parent_folder"`
+	Folder       *string    `json:"folder"`
+	Status       string     `json:"status"`
+	FeedID       *uuid.UUID `json:"feed_id"`
+	Error        *string    `json:"error"`
+}
+
+func databaseOPMLImportToOPMLImport(dbImport database.OpmlImport, dbItems []database.OpmlImportItem) OPMLImport {
+	opmlImport := OPMLImport{
+		ID:        dbImport.ID,
+		CreatedAt: dbImport.CreatedAt,
+		UpdatedAt: dbImport.UpdatedAt,
+		Status:    dbImport.Status,
+		Total:     dbImport.Total,
+		Items:     []OPMLImportItem{},
+	}
+	if dbImport.Error.Valid {
+		opmlImport.Error = &dbImport.Error.String
+	}
+	if dbImport.FinishedAt.Valid {
+		opmlImport.FinishedAt = &dbImport.FinishedAt.Time
+	}
+
+	for _, dbItem := range dbItems {
+		item := OPMLImportItem{
+			Title:  dbItem.Title,
+			URL:    dbItem.Url,
+			Status: dbItem.Status,
+		}
+		if dbItem.ParentFolder.Valid {
+			item.ParentFolder = &dbItem.ParentFolder.String
+		}
+		if dbItem.Folder.Valid {
+			item.Folder = &dbItem.Folder.String
+		}
+		if dbItem.FeedID.Valid {
+			item.FeedID = &dbItem.FeedID.UUID
+		}
+		if dbItem.Error.Valid {
+			item.Error = &dbItem.Error.String
+		}
+		opmlImport.Items = append(opmlImport.Items, item)
+
+		switch dbItem.Status {
+		case "pending":
+			opmlImport.Pending++
+		case "created":
+			opmlImport.Created++
+		case "existing":
+			opmlImport.Existing++
+		case "failed":
+			opmlImport.Failed++
+		}
+	}
+	return opmlImport
+}
+
+type FilterRule struct {
+	ID         uuid.UUID  `json:"id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	UserID     uuid.UUID  `json:"user_id"`
+	FeedID     *uuid.UUID `json:"feed_id"`
+	Name       string     `json:"name"`
+	MatchField string     `json:"match_field"`
+	MatchType  string     `json:"match_type"`
+	Pattern    string     `json:"pattern"`
+	Action     string     `json:"action"`
+	Tag        *string    `json:"tag"`
+	Enabled    bool       `json:"enabled"`
+	MatchCount int64      `json:"match_count"`
+}
+
+func databaseFilterRuleToFilterRule(dbRule database.FilterRule, matchCount int64) FilterRule {
+	var feedID *uuid.UUID
+	if dbRule.FeedID.Valid {
+		feedID = &dbRule.FeedID.UUID
+	}
+	var tag *string
+	if dbRule.Tag.Valid {
+		tag = &dbRule.Tag.String
+	}
+	return FilterRule{
+		ID:         dbRule.ID,
+		CreatedAt:  dbRule.CreatedAt,
+		UpdatedAt:  dbRule.UpdatedAt,
+		UserID:     dbRule.UserID,
+		FeedID:     feedID,
+		Name:       dbRule.Name,
+		MatchField: dbRule.MatchField,
+		MatchType:  dbRule.MatchType,
+		Pattern:    dbRule.Pattern,
+		Action:     dbRule.Action,
+		Tag:        tag,
+		Enabled:    dbRule.Enabled,
+		MatchCount: matchCount,
+	}
+}
+
+func databaseFilterRuleRowsToFilterRules(rows []database.GetFilterRulesRow) []FilterRule {
+	rules := []FilterRule{}
+	for _, row := range rows {
+		rules = append(rules, databaseFilterRuleToFilterRule(database.FilterRule{
+			ID:         row.ID,
+			CreatedAt:  row.CreatedAt,
+			UpdatedAt:  row.UpdatedAt,
+			UserID:     row.UserID,
+			FeedID:     row.FeedID,
+			Name:       row.Name,
+			MatchField: row.MatchField,
+			MatchType:  row.MatchType,
+			Pattern:    row.Pattern,
+			Action:     row.Action,
+			Tag:        row.Tag,
+			Enabled:    row.Enabled,
+		}, row.MatchCount))
+	}
+	return rules
+}
+
+// PostSearchResult is a search hit. TitleHighlight and Snippet are HTML:
+// the feed's text is escaped and matches are wrapped in <mark> tags.
+type PostSearchResult struct {
+	Post
+	Rank           float32 `json:"rank"`
+	TitleHighlight string  `json:"title_highlight"`
+	Snippet        string  `json:"snippet"`
+}
+
+type PostSearchPage struct {
+	Results    []PostSearchResult `json:"results"`
+	NextOffset *int               `json:"next_offset"`
+}
+
+func databaseSearchRowsToResults(rows []database.SearchPostsForUserRow) []PostSearchResult {
+	results := []PostSearchResult{}
+	for _, row := range rows {
+		results = append(results, PostSearchResult{
+			Post: databasePostWithStateToPost(database.Post{
+				ID:          row.ID,
+				CreatedAt:   row.CreatedAt,
+				UpdatedAt:   row.UpdatedAt,
+				Title:       row.Title,
+				Description: row.Description,
+				PublishedAt: row.PublishedAt,
+				Url:         row.Url,
+				FeedID:      row.FeedID,
+				Content:     row.Content,
+				Author:      row.Author,
+				Categories:  row.Categories,
+			}, row.Read, row.ReadAt, row.Starred, row.StarredAt),
+			Rank:           row.Rank,
+			TitleHighlight: row.TitleHighlight,
+			Snippet:        row.Snippet,
+		})
+	}
+	return results
+}
+
+// AccountExport is everything a user has stored, as downloaded from
+// GET /v1/user/export. OPML holds the subscriptions in a form other feed
+// readers can import.
+type AccountExport struct {
+	ExportedAt           time.Time             `json:"exported_at"`
+	User                 User                  `json:"user"`
+	FeedFollows          []FeedFollowWithFeed  `json:"feed_follows"`
+	Folders              []Folder              `json:"folders"`
+	Tags                 []TagWithCount        `json:"tags"`
+	StarredPosts         []Post                `json:"starred_posts"`
+	Annotations          []AnnotatedPost       `json:"annotations"`
+	SavedSearches        []SavedSearch         `json:"saved_searches"`
+	FilterRules          []FilterRule          `json:"filter_rules"`
+	PersonalAccessTokens []PersonalAccessToken `json:"personal_access_tokens"`
+	OPML                 string                `json:"opml"`
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Jayant-Verma/rssagg/internal/database"
+	"github.com/google/uuid"
+)
+
+// maxOPMLSize caps the size of an uploaded OPML file.
+const maxOPMLSize = 5 << 20
+
+// maxOPMLEntries caps how many feeds a single import may subscribe to.
+const maxOPMLEntries = 2000
+
+type opmlDocument struct {
+	XMLName xml.Name `xml:"opml"`
+	Version string   `xml:"version,attr"`
+	Head    opmlHead `xml:"head"`
+	Body    opmlBody `xml:"body"`
+}
+
+type opmlHead struct {
+	Title       string `xml:"title"`
+	DateCreated string `xml:"dateCreated,omitempty"`
+	OwnerName   string `xml:"ownerName,omitempty"`
+}
+
+type opmlBody struct {
+	Outlines []opmlOutline `xml:"outline"`
+}
+
+type opmlOutline struct {
+	Text     string        `xml:"text,attr"`
+	Title    string        `xml:"title,attr,omitempty"`
+	Type     string        `xml:"type,attr,omitempty"`
+	XMLURL   string        `xml:"xmlUrl,attr,omitempty"`
+	HTMLURL  string        `xml:"htmlUrl,attr,omitempty"`
+	Outlines []opmlOutline `xml:"outline"`
+}
+
+// opmlEntry is a feed outline from an OPML file along with the names of the
+// folders it was nested in. Folder is empty for feeds at the top level and
+// ParentFolder is only set when the feed was nested two levels deep.
+type opmlEntry struct {
+	Title        string
+	URL          string
+	ParentFolder string
+	Folder       string
+}
+
+// parseOPML reads the feed outlines from an OPML document. The returned
+// error is meant to be shown to the client.
+func parseOPML(dat []byte) ([]opmlEntry, error) {
+	doc := opmlDocument{}
+	err := xml.Unmarshal(dat, &doc)
+	if err != nil {
+		return nil, errors.New("file is not a valid OPML document")
+	}
+
+	entries := collectOPMLEntries(doc.Body.Outlines, nil, nil)
+	if len(entries) == 0 {
+		return nil, errors.New("file contains no feeds")
+	}
+	return entries, nil
+}
+
+// collectOPMLEntries walks the outline tree. Outlines without an xmlUrl are
+// folders; since folders only nest one level deep, anything nested further
+// is filed under the second level folder it is in.
+func collectOPMLEntries(outlines []opmlOutline, folders []string, entries []opmlEntry) []opmlEntry {
+	for _, outline := range outlines {
+		title := strings.TrimSpace(outline.Title)
+		if title == "" {
+			title = strings.TrimSpace(outline.Text)
+		}
+
+		if feedURL := strings.TrimSpace(outline.XMLURL); feedURL != "" {
+			entry := opmlEntry{Title: title, URL: feedURL}
+			switch len(folders) {
+			case 1:
+				entry.Folder = folders[0]
+			case 2:
+				entry.ParentFolder = folders[0]
+				entry.Folder = folders[1]
+			}
+			entries = append(entries, entry)
+			continue
+		}
+
+		nested := folders
+		if title != "" && len(folders) < 2 {
+			nested = append(folders[:len(folders):len(folders)], truncateRunes(title, maxFolderNameLength))
+		}
+		entries = collectOPMLEntries(outline.Outlines, nested, entries)
+	}
+	return entries
+}
+
+// buildOPML lays out the user's subscriptions as an OPML 2.0 document with
+// one outline per folder. Folders come first, in the order given, followed
+// by the feeds that are not in any folder.
+func buildOPML(user database.User, folders []database.Folder, follows []database.GetFeedFollowsForExportRow, now time.Time) opmlDocument {
+	doc := opmlDocument{Version: "2.0"}
+	doc.Head.Title = fmt.Sprintf("%s's subscriptions", user.Name)
+	doc.Head.DateCreated = now.UTC().Format(time.RFC1123Z)
+	doc.Head.OwnerName = user.Name
+
+	feedsByFolder := map[uuid.UUID][]opmlOutline{}
+	for _, follow := range follows {
+		outline := opmlOutline{
+			Text:   follow.Title,
+			Title:  follow.Title,
+			Type:   "rss",
+			XMLURL: follow.Url,
+		}
+		if follow.SiteUrl.Valid {
+			outline.HTMLURL = follow.SiteUrl.String
+		}
+		// uuid.Nil collects the feeds outside any folder.
+		feedsByFolder[follow.FolderID.UUID] = append(feedsByFolder[follow.FolderID.UUID], outline)
+	}
+
+	childFolders := map[uuid.UUID][]database.Folder{}
+	for _, folder := range folders {
+		childFolders[folder.ParentID.UUID] = append(childFolders[folder.ParentID.UUID], folder)
+	}
+
+	var folderOutline func(folder database.Folder) opmlOutline
+	folderOutline = func(folder database.Folder) opmlOutline {
+		outline := opmlOutline{Text: folder.Name, Title: folder.Name}
+		for _, child := range childFolders[folder.ID] {
+			outline.Outlines = append(outline.Outlines, folderOutline(child))
+		}
+		outline.Outlines = append(outline.Outlines, feedsByFolder[folder.ID]...)
+		return outline
+	}
+
+	for _, folder := range childFolders[uuid.Nil] {
+		doc.Body.Outlines = append(doc.Body.Outlines, folderOutline(folder))
+	}
+	doc.Body.Outlines = append(doc.Body.Outlines, feedsByFolder[uuid.Nil]...)
+	return doc
+}
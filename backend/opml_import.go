@@ -0,0 +1,235 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Jayant-Verma/rssagg/internal/database"
+	"github.com/google/uuid"
+)
+
+// opmlImportConcurrency is how many feeds of an import are fetched at once.
+const opmlImportConcurrency = 4
+
+// maxRunningOPMLImports caps how many imports run at once across all users.
+// Imports past the cap stay pending until a slot frees up.
+const maxRunningOPMLImports = 3
+
+var opmlImportSlots = make(chan struct{}, maxRunningOPMLImports)
+
+// resumeOPMLImports picks up imports that were still queued or running when
+// the server last stopped. Items that were already processed are skipped.
+func (apiCfg *apiConfig) resumeOPMLImports() {
+	imports, err := apiCfg.DB.GetUnfinishedOPMLImports(context.Background())
+	if err != nil {
+		log.Printf("Failed to get unfinished OPML imports: %v", err)
+		return
+	}
+
+	for _, opmlImport := range imports {
+		user, err := apiCfg.DB.GetUserByID(context.Background(), opmlImport.UserID)
+		if err != nil {
+			log.Printf("Failed to get user for OPML import %v: %v", opmlImport.ID, err)
+			continue
+		}
+		apiCfg.runOPMLImport(opmlImport.ID, user)
+	}
+}
+
+// runOPMLImport subscribes user to every pending item of the import and
+// records the outcome of each one.
+func (apiCfg *apiConfig) runOPMLImport(importID uuid.UUID, user database.User) {
+	ctx := context.Background()
+
+	opmlImportSlots <- struct{}{}
+	defer func() { <-opmlImportSlots }()
+
+	err := apiCfg.DB.SetOPMLImportStatus(ctx, database.SetOPMLImportStatusParams{
+		ID:     importID,
+		Status: "running",
+	})
+	if err != nil {
+		log.Printf("Failed to start OPML import %v: %v", importID, err)
+		return
+	}
+
+	items, err := apiCfg.DB.GetPendingOPMLImportItems(ctx, importID)
+	if err != nil {
+		apiCfg.failOPMLImport(importID, err)
+		return
+	}
+
+	folders := map[[2]string]uuid.NullUUID{}
+	sem := make(chan struct{}, opmlImportConcurrency)
+	wg := &sync.WaitGroup{}
+	for _, item := range items {
+		// Folders are resolved here rather than in the workers so that two
+		// feeds in the same new folder don't race to create it.
+		key := [2]string{item.ParentFolder.String, item.Folder.String}
+		folderID, ok := folders[key]
+		if !ok {
+			folderID, err = apiCfg.ensureFolderPath(ctx, user, item.ParentFolder.String, item.Folder.String)
+			if err != nil {
+				wg.Wait()
+				apiCfg.failOPMLImport(importID, err)
+				return
+			}
+			folders[key] = folderID
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(item database.OpmlImportItem, folderID uuid.NullUUID) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			apiCfg.importOPMLItem(ctx, user, item, folderID)
+		}(item, folderID)
+	}
+	wg.Wait()
+
+	err = apiCfg.DB.SetOPMLImportStatus(ctx, database.SetOPMLImportStatusParams{
+		ID:     importID,
+		Status: "done",
+	})
+	if err != nil {
+		log.Printf("Failed to finish OPML import %v: %v", importID, err)
+	}
+}
+
+func (apiCfg *apiConfig) failOPMLImport(importID uuid.UUID, err error) {
+	log.Printf("OPML import %v failed: %v", importID, err)
+	err = apiCfg.DB.SetOPMLImportStatus(context.Background(), database.SetOPMLImportStatusParams{
+		ID:     importID,
+		Status: "failed",
+		Error:  sql.NullString{String: "Internal error while importing", Valid: true},
+	})
+	if err != nil {
+		log.Printf("Failed to mark OPML import %v as failed: %v", importID, err)
+	}
+}
+
+// importOPMLItem subscribes to a single feed from the file. Subscriptions the
+// user already had keep their folder and title.
+func (apiCfg *apiConfig) importOPMLItem(ctx context.Context, user database.User, item database.OpmlImportItem, folderID uuid.NullUUID) {
+	result := database.SetOPMLImportItemResultParams{ID: item.ID}
+
+	sub, err := apiCfg.subscribeToFeed(ctx, user, truncateRunes(item.Title, maxFeedNameLength), item.Url)
+	if err == nil {
+		err = apiCfg.applyOPMLItemSettings(ctx, user, item, sub, folderID)
+	}
+
+	var subErr *subscribeError
+	switch {
+	case errors.As(err, &subErr):
+		result.Status = "failed"
+		result.Error = sql.NullString{String: subErr.Message, Valid: true}
+	case err != nil:
+		log.Printf("Failed to import %s for OPML import %v: %v", item.Url, item.ImportID, err)
+		result.Status = "failed"
+		result.Error = sql.NullString{String: "Internal error while subscribing", Valid: true}
+	case sub.Created:
+		result.Status = "created"
+		result.FeedID = uuid.NullUUID{UUID: sub.Feed.ID, Valid: true}
+	default:
+		result.Status = "existing"
+		result.FeedID = uuid.NullUUID{UUID: sub.Feed.ID, Valid: true}
+	}
+
+	err = apiCfg.DB.SetOPMLImportItemResult(ctx, result)
+	if err != nil {
+		log.Printf("Failed to record result of %s for OPML import %v: %v", item.Url, item.ImportID, err)
+	}
+}
+
+func (apiCfg *apiConfig) applyOPMLItemSettings(ctx context.Context, user database.User, item database.OpmlImportItem, sub subscription, folderID uuid.NullUUID) error {
+	if !sub.Followed {
+		return nil
+	}
+
+	feedFollow := sub.FeedFollow
+	if folderID.Valid {
+		_, err := apiCfg.DB.SetFeedFollowFolder(ctx, database.SetFeedFollowFolderParams{
+			ID:       feedFollow.ID,
+			UserID:   user.ID,
+			FolderID: folderID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// New feeds are named after the outline already; for feeds that existed
+	// the outline title is kept as the user's own title for it.
+	title := truncateRunes(item.Title, maxFeedNameLength)
+	if !sub.Created && title != "" && title != sub.Feed.Name {
+		_, err := apiCfg.DB.UpdateFeedFollowSettings(ctx, database.UpdateFeedFollowSettingsParams{
+			ID:             feedFollow.ID,
+			UserID:         user.ID,
+			Title:          sql.NullString{String: title, Valid: true},
+			Priority:       feedFollow.Priority,
+			Muted:          feedFollow.Muted,
+			ShowInTimeline: feedFollow.ShowInTimeline,
+			Notifications:  feedFollow.Notifications,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ensureFolderPath returns the folder the OPML outline names, creating it and
+// its parent as needed. Both names empty means no folder.
+func (apiCfg *apiConfig) ensureFolderPath(ctx context.Context, user database.User, parentName, name string) (uuid.NullUUID, error) {
+	if name == "" {
+		return uuid.NullUUID{}, nil
+	}
+
+	parentID := uuid.NullUUID{}
+	if parentName != "" {
+		parent, err := apiCfg.ensureFolder(ctx, user, uuid.NullUUID{}, parentName)
+		if err != nil {
+			return uuid.NullUUID{}, err
+		}
+		parentID = uuid.NullUUID{UUID: parent.ID, Valid: true}
+	}
+
+	folder, err := apiCfg.ensureFolder(ctx, user, parentID, name)
+	if err != nil {
+		return uuid.NullUUID{}, err
+	}
+	return uuid.NullUUID{UUID: folder.ID, Valid: true}, nil
+}
+
+func (apiCfg *apiConfig) ensureFolder(ctx context.Context, user database.User, parentID uuid.NullUUID, name string) (database.Folder, error) {
+	folder, err := apiCfg.DB.GetFolderByName(ctx, database.GetFolderByNameParams{
+		UserID:   user.ID,
+		ParentID: parentID,
+		Name:     name,
+	})
+	if err == nil || !errors.Is(err, sql.ErrNoRows) {
+		return folder, err
+	}
+
+	folder, err = apiCfg.DB.CreateFolder(ctx, database.CreateFolderParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+		UserID:    user.ID,
+		ParentID:  parentID,
+		Name:      name,
+	})
+	if isUniqueViolation(err) {
+		// Created by the user in the meantime.
+		return apiCfg.DB.GetFolderByName(ctx, database.GetFolderByNameParams{
+			UserID:   user.ID,
+			ParentID: parentID,
+			Name:     name,
+		})
+	}
+	return folder, err
+}
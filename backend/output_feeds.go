@@ -0,0 +1,222 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"time"
+)
+
+// outputFeed is one of a user's post listings rendered as a feed document.
+type outputFeed struct {
+	Title   string
+	SelfURL string
+	Updated time.Time
+	Posts   []Post
+}
+
+var outputFeedContentTypes = map[string]string{
+	feedFormatRSS:  "application/rss+xml; charset=utf-8",
+	feedFormatAtom: "application/atom+xml; charset=utf-8",
+	feedFormatJSON: "application/feed+json; charset=utf-8",
+}
+
+func (f outputFeed) render(format string) ([]byte, error) {
+	switch format {
+	case feedFormatAtom:
+		return f.renderAtom()
+	case feedFormatJSON:
+		return f.renderJSON()
+	default:
+		return f.renderRSS()
+	}
+}
+
+type rssOutput struct {
+	XMLName   xml.Name         `xml:"rss"`
+	Version   string           `xml:"version,attr"`
+	AtomNS    string           `xml:"xmlns:atom,attr"`
+	ContentNS string           `xml:"xmlns:content,attr"`
+	DCNS      string           `xml:"xmlns:dc,attr"`
+	Channel   rssOutputChannel `xml:"channel"`
+}
+
+type rssOutputChannel struct {
+	Title         string          `xml:"title"`
+	Link          string          `xml:"link"`
+	Description   string          `xml:"description"`
+	SelfLink      rssOutputLink   `xml:"atom:link"`
+	LastBuildDate string          `xml:"lastBuildDate"`
+	Items         []rssOutputItem `xml:"item"`
+}
+
+type rssOutputLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type rssOutputItem struct {
+	Title       string        `xml:"title"`
+	Link        string        `xml:"link"`
+	Description string        `xml:"description,omitempty"`
+	Content     string        `xml:"content:encoded,omitempty"`
+	Creator     string        `xml:"dc:creator,omitempty"`
+	Categories  []string      `xml:"category"`
+	GUID        rssOutputGUID `xml:"guid"`
+	PubDate     string        `xml:"pubDate"`
+}
+
+type rssOutputGUID struct {
+	IsPermaLink string `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+func (f outputFeed) renderRSS() ([]byte, error) {
+	doc := rssOutput{
+		Version:   "2.0",
+		AtomNS:    "http://www.w3.org/2005/Atom",
+		ContentNS: "http://purl.org/rss/1.0/modules/content/",
+		DCNS:      "http://purl.org/dc/elements/1.1/",
+		Channel: rssOutputChannel{
+			Title:         f.Title,
+			Link:          f.SelfURL,
+			Description:   f.Title,
+			SelfLink:      rssOutputLink{Href: f.SelfURL, Rel: "self", Type: "application/rss+xml"},
+			LastBuildDate: f.Updated.UTC().Format(time.RFC1123Z),
+		},
+	}
+	for _, post := range f.Posts {
+		item := rssOutputItem{
+			Title:      post.Title,
+			Link:       post.URL,
+			Categories: post.Categories,
+			GUID:       rssOutputGUID{IsPermaLink: "false", Value: "urn:uuid:" + post.ID.String()},
+			PubDate:    post.PublishedAt.UTC().Format(time.RFC1123Z),
+		}
+		if post.Description != nil {
+			item.Description = *post.Description
+		}
+		if post.Content != nil {
+			item.Content = *post.Content
+		}
+		if post.Author != nil {
+			item.Creator = *post.Author
+		}
+		doc.Channel.Items = append(doc.Channel.Items, item)
+	}
+	return marshalXMLDocument(doc)
+}
+
+type atomOutput struct {
+	XMLName xml.Name          `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string            `xml:"title"`
+	ID      string            `xml:"id"`
+	Updated string            `xml:"updated"`
+	Links   []atomLink        `xml:"link"`
+	Entries []atomOutputEntry `xml:"entry"`
+}
+
+type atomOutputEntry struct {
+	Title      string         `xml:"title"`
+	ID         string         `xml:"id"`
+	Links      []atomLink     `xml:"link"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+	Authors    []atomPerson   `xml:"author"`
+	Categories []atomCategory `xml:"category"`
+	Summary    *atomText      `xml:"summary"`
+	Content    *atomText      `xml:"content"`
+}
+
+type atomText struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+func (f outputFeed) renderAtom() ([]byte, error) {
+	doc := atomOutput{
+		Title:   f.Title,
+		ID:      f.SelfURL,
+		Updated: f.Updated.UTC().Format(time.RFC3339),
+		Links:   []atomLink{{Href: f.SelfURL, Rel: "self"}},
+	}
+	for _, post := range f.Posts {
+		entry := atomOutputEntry{
+			Title:     post.Title,
+			ID:        "urn:uuid:" + post.ID.String(),
+			Links:     []atomLink{{Href: post.URL, Rel: "alternate"}},
+			Published: post.PublishedAt.UTC().Format(time.RFC3339),
+			Updated:   post.UpdatedAt.UTC().Format(time.RFC3339),
+		}
+		if post.Author != nil {
+			entry.Authors = []atomPerson{{Name: *post.Author}}
+		}
+		for _, category := range post.Categories {
+			entry.Categories = append(entry.Categories, atomCategory{Term: category})
+		}
+		if post.Description != nil {
+			entry.Summary = &atomText{Type: "html", Value: *post.Description}
+		}
+		if post.Content != nil {
+			entry.Content = &atomText{Type: "html", Value: *post.Content}
+		}
+		doc.Entries = append(doc.Entries, entry)
+	}
+	return marshalXMLDocument(doc)
+}
+
+type jsonFeedOutput struct {
+	Version string               `json:"version"`
+	Title   string               `json:"title"`
+	FeedURL string               `json:"feed_url"`
+	Items   []jsonFeedOutputItem `json:"items"`
+}
+
+type jsonFeedOutputItem struct {
+	ID            string           `json:"id"`
+	URL           string           `json:"url"`
+	Title         string           `json:"title"`
+	ContentHTML   string           `json:"content_html"`
+	DatePublished string           `json:"date_published"`
+	DateModified  string           `json:"date_modified"`
+	Authors       []jsonFeedAuthor `json:"authors,omitempty"`
+	Tags          []string         `json:"tags,omitempty"`
+}
+
+func (f outputFeed) renderJSON() ([]byte, error) {
+	doc := jsonFeedOutput{
+		Version: "https://jsonfeed.org/version/1.1",
+		Title:   f.Title,
+		FeedURL: f.SelfURL,
+		Items:   []jsonFeedOutputItem{},
+	}
+	for _, post := range f.Posts {
+		item := jsonFeedOutputItem{
+			ID:            post.ID.String(),
+			URL:           post.URL,
+			Title:         post.Title,
+			DatePublished: post.PublishedAt.UTC().Format(time.RFC3339),
+			DateModified:  post.UpdatedAt.UTC().Format(time.RFC3339),
+			Tags:          post.Categories,
+		}
+		if post.Description != nil {
+			item.ContentHTML = *post.Description
+		}
+		if post.Content != nil {
+			item.ContentHTML = *post.Content
+		}
+		if post.Author != nil {
+			item.Authors = []jsonFeedAuthor{{Name: *post.Author}}
+		}
+		doc.Items = append(doc.Items, item)
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+func marshalXMLDocument(doc interface{}) ([]byte, error) {
+	dat, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), dat...), nil
+}
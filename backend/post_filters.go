@@ -0,0 +1,206 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultPostsPageSize = 50
+	maxPostsPageSize     = 200
+)
+
+// postCursor points at the last post of a page. Pages are ordered by
+// publication time with the post ID breaking ties, so the pair is unique.
+type postCursor struct {
+	PublishedAt time.Time
+	ID          uuid.UUID
+}
+
+func (c postCursor) encode() string {
+	raw := c.PublishedAt.UTC().Format(time.RFC3339Nano) + "|" + c.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodePostCursor(s string) (postCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return postCursor{}, errors.New("cursor is not valid")
+	}
+	publishedAt, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return postCursor{}, errors.New("cursor is not valid")
+	}
+
+	cursor := postCursor{}
+	cursor.PublishedAt, err = time.Parse(time.RFC3339Nano, publishedAt)
+	if err != nil {
+		return postCursor{}, errors.New("cursor is not valid")
+	}
+	cursor.ID, err = uuid.Parse(id)
+	if err != nil {
+		return postCursor{}, errors.New("cursor is not valid")
+	}
+	return cursor, nil
+}
+
+// postFilters holds the query string options accepted by GET /v1/posts.
+type postFilters struct {
+	FeedID      uuid.NullUUID
+	FolderID    uuid.NullUUID
+	Tag         sql.NullString
+	Since       sql.NullTime
+	Until       sql.NullTime
+	Read        sql.NullBool
+	Starred     bool
+	Before      *postCursor
+	After       *postCursor
+	OldestFirst bool
+	Limit       int
+}
+
+// parsePostFilters reads the post listing options from the query string.
+// The returned error is meant to be shown to the client.
+func parsePostFilters(query url.Values) (postFilters, error) {
+	filters := postFilters{Limit: defaultPostsPageSize}
+
+	if raw := query.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > maxPostsPageSize {
+			return postFilters{}, fmt.Errorf("limit must be between 1 and %d", maxPostsPageSize)
+		}
+		filters.Limit = limit
+	}
+
+	var err error
+	filters.FeedID, err = parseUUIDParam(query, "feed_id")
+	if err != nil {
+		return postFilters{}, err
+	}
+	filters.FolderID, err = parseUUIDParam(query, "folder_id")
+	if err != nil {
+		return postFilters{}, err
+	}
+	if tag := strings.TrimSpace(query.Get("tag")); tag != "" {
+		filters.Tag = sql.NullString{String: tag, Valid: true}
+	}
+	filters.Since, err = parseTimeParam(query, "since")
+	if err != nil {
+		return postFilters{}, err
+	}
+	filters.Until, err = parseTimeParam(query, "until")
+	if err != nil {
+		return postFilters{}, err
+	}
+
+	switch query.Get("read") {
+	case "":
+	case "true":
+		filters.Read = sql.NullBool{Bool: true, Valid: true}
+	case "false":
+		filters.Read = sql.NullBool{Bool: false, Valid: true}
+	default:
+		return postFilters{}, errors.New("read must be true or false")
+	}
+	// unread=true predates the read filter and is kept for older clients.
+	if query.Get("unread") == "true" {
+		filters.Read = sql.NullBool{Bool: false, Valid: true}
+	}
+	filters.Starred = query.Get("starred") == "true"
+
+	switch query.Get("order") {
+	case "", "newest":
+	case "oldest":
+		filters.OldestFirst = true
+	default:
+		return postFilters{}, errors.New("order must be newest or oldest")
+	}
+
+	if raw := query.Get("before"); raw != "" {
+		cursor, err := decodePostCursor(raw)
+		if err != nil {
+			return postFilters{}, err
+		}
+		filters.Before = &cursor
+	}
+	if raw := query.Get("after"); raw != "" {
+		cursor, err := decodePostCursor(raw)
+		if err != nil {
+			return postFilters{}, err
+		}
+		filters.After = &cursor
+	}
+	// Each direction pages with one cursor only.
+	if filters.OldestFirst && filters.Before != nil {
+		return postFilters{}, errors.New("before can't be used with order=oldest, pass the next_cursor as after")
+	}
+	if !filters.OldestFirst && filters.After != nil {
+		return postFilters{}, errors.New("after can only be used with order=oldest, pass the next_cursor as before")
+	}
+
+	return filters, nil
+}
+
+func parseUUIDParam(query url.Values, key string) (uuid.NullUUID, error) {
+	raw := query.Get(key)
+	if raw == "" {
+		return uuid.NullUUID{}, nil
+	}
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		return uuid.NullUUID{}, fmt.Errorf("%s is not a valid ID", key)
+	}
+	return uuid.NullUUID{UUID: id, Valid: true}, nil
+}
+
+// parseTimeParam accepts either a full RFC 3339 timestamp or a plain date,
+// which is taken as midnight UTC.
+func parseTimeParam(query url.Values, key string) (sql.NullTime, error) {
+	raw := query.Get(key)
+	if raw == "" {
+		return sql.NullTime{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		t, err = time.Parse(time.DateOnly, raw)
+	}
+	if err != nil {
+		return sql.NullTime{}, fmt.Errorf("%s must be a date or an RFC 3339 timestamp", key)
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}, nil
+}
+
+// newPostsPage trims posts, fetched with one row more than limit, down to a
+// page and sets the cursor for the next one when there is more to read.
+func newPostsPage(posts []Post, limit int) PostsPage {
+	page := PostsPage{Posts: posts}
+	if len(posts) > limit {
+		page.Posts = posts[:limit]
+		last := page.Posts[len(page.Posts)-1]
+		cursor := postCursor{PublishedAt: last.PublishedAt, ID: last.ID}.encode()
+		page.NextCursor = &cursor
+	}
+	return page
+}
+
+func cursorTime(c *postCursor) sql.NullTime {
+	if c == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: c.PublishedAt, Valid: true}
+}
+
+func cursorID(c *postCursor) uuid.NullUUID {
+	if c == nil {
+		return uuid.NullUUID{}
+	}
+	return uuid.NullUUID{UUID: c.ID, Valid: true}
+}
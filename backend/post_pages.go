@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+
+	"github.com/Jayant-Verma/rssagg/internal/database"
+	"github.com/google/uuid"
+)
+
+// Each post listing has one query per direction: a single query ordered by
+// a CASE on the direction can't walk the (published_at, id) index, which is
+// what makes cursor pagination cheap on large tables.
+
+// getPostsPage fetches up to limit posts of the user's timeline matching
+// filters, newest first unless filters.OldestFirst is set.
+func (apiCfg *apiConfig) getPostsPage(ctx context.Context, user database.User, filters postFilters, limit int32) ([]Post, error) {
+	if filters.OldestFirst {
+		rows, err := apiCfg.DB.GetPostsForUserOldestFirst(ctx, database.GetPostsForUserOldestFirstParams{
+			UserID:           user.ID,
+			FeedID:           filters.FeedID,
+			FolderID:         filters.FolderID,
+			Tag:              filters.Tag,
+			Since:            filters.Since,
+			Until:            filters.Until,
+			Read:             filters.Read,
+			AfterPublishedAt: cursorTime(filters.After),
+			AfterID:          cursorID(filters.After),
+			Limit:            limit,
+		})
+		if err != nil {
+			return nil, err
+		}
+		posts := []database.GetPostsForUserRow{}
+		for _, row := range rows {
+			posts = append(posts, database.GetPostsForUserRow(row))
+		}
+		return databasePostRowsToPosts(posts), nil
+	}
+
+	rows, err := apiCfg.DB.GetPostsForUser(ctx, database.GetPostsForUserParams{
+		UserID:            user.ID,
+		FeedID:            filters.FeedID,
+		FolderID:          filters.FolderID,
+		Tag:               filters.Tag,
+		Since:             filters.Since,
+		Until:             filters.Until,
+		Read:              filters.Read,
+		BeforePublishedAt: cursorTime(filters.Before),
+		BeforeID:          cursorID(filters.Before),
+		Limit:             limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return databasePostRowsToPosts(rows), nil
+}
+
+// getStarredPostsPage is getPostsPage for the user's starred posts.
+func (apiCfg *apiConfig) getStarredPostsPage(ctx context.Context, user database.User, filters postFilters, limit int32) ([]Post, error) {
+	if filters.OldestFirst {
+		rows, err := apiCfg.DB.GetStarredPostsForUserOldestFirst(ctx, database.GetStarredPostsForUserOldestFirstParams{
+			UserID:           user.ID,
+			FeedID:           filters.FeedID,
+			FolderID:         filters.FolderID,
+			Tag:              filters.Tag,
+			Since:            filters.Since,
+			Until:            filters.Until,
+			Read:             filters.Read,
+			AfterPublishedAt: cursorTime(filters.After),
+			AfterID:          cursorID(filters.After),
+			Limit:            limit,
+		})
+		if err != nil {
+			return nil, err
+		}
+		starred := []database.GetStarredPostsForUserRow{}
+		for _, row := range rows {
+			starred = append(starred, database.GetStarredPostsForUserRow(row))
+		}
+		return databaseStarredPostRowsToPosts(starred), nil
+	}
+
+	rows, err := apiCfg.DB.GetStarredPostsForUser(ctx, database.GetStarredPostsForUserParams{
+		UserID:            user.ID,
+		FeedID:            filters.FeedID,
+		FolderID:          filters.FolderID,
+		Tag:               filters.Tag,
+		Since:             filters.Since,
+		Until:             filters.Until,
+		Read:              filters.Read,
+		BeforePublishedAt: cursorTime(filters.Before),
+		BeforeID:          cursorID(filters.Before),
+		Limit:             limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return databaseStarredPostRowsToPosts(rows), nil
+}
+
+// getSavedSearchPostsPage is getPostsPage for the posts matching a saved
+// search.
+func (apiCfg *apiConfig) getSavedSearchPostsPage(ctx context.Context, user database.User, savedSearchID uuid.UUID, filters postFilters, limit int32) ([]Post, error) {
+	if filters.OldestFirst {
+		rows, err := apiCfg.DB.GetSavedSearchPostsOldestFirst(ctx, database.GetSavedSearchPostsOldestFirstParams{
+			UserID:           user.ID,
+			SavedSearchID:    savedSearchID,
+			Read:             filters.Read,
+			AfterPublishedAt: cursorTime(filters.After),
+			AfterID:          cursorID(filters.After),
+			Limit:            limit,
+		})
+		if err != nil {
+			return nil, err
+		}
+		matches := []database.GetSavedSearchPostsRow{}
+		for _, row := range rows {
+			matches = append(matches, database.GetSavedSearchPostsRow(row))
+		}
+		return databaseSavedSearchPostRowsToPosts(matches), nil
+	}
+
+	rows, err := apiCfg.DB.GetSavedSearchPosts(ctx, database.GetSavedSearchPostsParams{
+		UserID:            user.ID,
+		SavedSearchID:     savedSearchID,
+		Read:              filters.Read,
+		BeforePublishedAt: cursorTime(filters.Before),
+		BeforeID:          cursorID(filters.Before),
+		Limit:             limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return databaseSavedSearchPostRowsToPosts(rows), nil
+}
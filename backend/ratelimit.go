@@ -0,0 +1,55 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// maxRateLimitKeys is how many keys a limiter tracks before it sweeps out
+// those whose window has passed.
+const maxRateLimitKeys = 10000
+
+// rateLimiter allows up to limit events per key in each window. Counts live
+// in memory, so they start over when the server restarts.
+type rateLimiter struct {
+	mu     sync.Mutex
+	limit  int
+	window time.Duration
+	hits   map[string]rateWindow
+}
+
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+func newRateLimiter(limit int, window time.Duration) *rateLimiter {
+	return &rateLimiter{
+		limit:  limit,
+		window: window,
+		hits:   map[string]rateWindow{},
+	}
+}
+
+// allow records an event for key and reports whether it is within the limit.
+func (l *rateLimiter) allow(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if len(l.hits) >= maxRateLimitKeys {
+		for k, hit := range l.hits {
+			if now.Sub(hit.start) >= l.window {
+				delete(l.hits, k)
+			}
+		}
+	}
+
+	hit, ok := l.hits[key]
+	if !ok || now.Sub(hit.start) >= l.window {
+		hit = rateWindow{start: now}
+	}
+	hit.count++
+	l.hits[key] = hit
+	return hit.count <= l.limit
+}
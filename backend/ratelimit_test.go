@@ -0,0 +1,27 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	limiter := newRateLimiter(2, time.Hour)
+
+	steps := []struct {
+		key  string
+		want bool
+	}{
+		{"a@example.com", true},
+		{"a@example.com", true},
+		{"b@example.com", true},
+		{"a@example.com", false},
+		{"b@example.com", true},
+		{"b@example.com", false},
+	}
+	for i, step := range steps {
+		if got := limiter.allow(step.key); got != step.want {
+			t.Errorf("step %d: allow(%q) = %v, want %v", i, step.key, got, step.want)
+		}
+	}
+}
@@ -1,50 +1,311 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"encoding/xml"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
-	"time"
+	"net/url"
+	"strings"
 )
 
+// maxFeedSize caps how much of a remote document we are willing to read.
+const maxFeedSize = 10 << 20
+
+const (
+	feedFormatRSS  = "rss"
+	feedFormatAtom = "atom"
+	feedFormatJSON = "json"
+)
+
+var errUnsupportedFeed = errors.New("document is not an RSS, Atom or JSON feed")
+
+// RSSFeed is the normalized form every supported feed format is parsed into.
 type RSSFeed struct {
 	Channel struct {
-		Title       string    `xml:"title"`
-		Link        string    `xml:"link"`
+		Title string `xml:"title"`
+		// Link is the site the feed belongs to, picked from Links since
+		// RSS feeds often carry an atom:link to themselves next to it.
+		Link        string    `xml:"-"`
+		Links       []rssLink `xml:"link"`
 		Description string    `xml:"description"`
 		Language    string    `xml:"language"`
 		Item        []RSSItem `xml:"item"`
 	} `xml:"channel"`
 }
 
+type rssLink struct {
+	XMLName xml.Name
+	Value   string `xml:",chardata"`
+}
+
 type RSSItem struct {
 	Title       string `xml:"title"`
 	Link        string `xml:"link"`
 	Description string `xml:"description"`
+	Content     string `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
 	PubDate     string `xml:"pubDate"`
+	// Author is the RSS author element, which by the spec is an email
+	// address; many feeds put the name in dc:creator instead.
+	Author     string   `xml:"author"`
+	Creator    string   `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Categories []string `xml:"category"`
+}
+
+type atomFeed struct {
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle"`
+	Language string      `xml:"lang,attr"`
+	Links    []atomLink  `xml:"link"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+}
+
+type atomEntry struct {
+	Title      string         `xml:"title"`
+	Links      []atomLink     `xml:"link"`
+	Summary    string         `xml:"summary"`
+	Content    string         `xml:"content"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+	Authors    []atomPerson   `xml:"author"`
+	Categories []atomCategory `xml:"category"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
 }
 
-func urlToFeed(url string) (RSSFeed, error) {
-	httpClient := http.Client{
-		Timeout: time.Second * 10,
+type jsonFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url"`
+	Description string         `json:"description"`
+	Language    string         `json:"language"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+type jsonFeedItem struct {
+	URL           string `json:"url"`
+	Title         string `json:"title"`
+	Summary       string `json:"summary"`
+	ContentHTML   string `json:"content_html"`
+	ContentText   string `json:"content_text"`
+	DatePublished string `json:"date_published"`
+	DateModified  string `json:"date_modified"`
+	// author is JSON Feed 1.0, authors replaced it in 1.1.
+	Author  *jsonFeedAuthor  `json:"author"`
+	Authors []jsonFeedAuthor `json:"authors"`
+	Tags    []string         `json:"tags"`
+}
+
+type jsonFeedAuthor struct {
+	Name string `json:"name"`
+}
+
+// fetchURL downloads rawURL and returns its body together with the
+// response, so callers can look at the content type and the final
+// (redirected) URL.
+func fetchURL(ctx context.Context, rawURL string) ([]byte, *http.Response, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	err = checkFetchURL(u)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	resp, err := httpClient.Get(url)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
 	if err != nil {
-		return RSSFeed{}, err
+		return nil, nil, err
+	}
+
+	resp, err := feedHTTPClient.Do(req)
+	if err != nil {
+		return nil, nil, err
 	}
 	defer resp.Body.Close()
 
-	dat, err := io.ReadAll(resp.Body)
+	if resp.StatusCode >= 400 {
+		return nil, nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	dat, err := io.ReadAll(io.LimitReader(resp.Body, maxFeedSize))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return dat, resp, nil
+}
+
+func urlToFeed(feedURL string) (RSSFeed, error) {
+	dat, _, err := fetchURL(context.Background(), feedURL)
 	if err != nil {
 		return RSSFeed{}, err
 	}
+
+	rssFeed, _, err := parseFeed(dat)
+	return rssFeed, err
+}
+
+// parseFeed detects the format of dat and parses it into an RSSFeed.
+func parseFeed(dat []byte) (RSSFeed, string, error) {
+	dat = bytes.TrimLeft(dat, "\xef\xbb\xbf \t\r\n")
+	if len(dat) > 0 && dat[0] == '{' {
+		rssFeed, err := parseJSONFeed(dat)
+		return rssFeed, feedFormatJSON, err
+	}
+
+	root, err := xmlRootName(dat)
+	if err != nil {
+		return RSSFeed{}, "", err
+	}
+
+	switch root {
+	case "rss":
+		rssFeed := RSSFeed{}
+		err := xml.Unmarshal(dat, &rssFeed)
+		if err != nil {
+			return RSSFeed{}, "", err
+		}
+		for _, link := range rssFeed.Channel.Links {
+			if link.XMLName.Space == "" {
+				rssFeed.Channel.Link = strings.TrimSpace(link.Value)
+				break
+			}
+		}
+		for i, item := range rssFeed.Channel.Item {
+			if item.Creator != "" {
+				rssFeed.Channel.Item[i].Author = item.Creator
+			}
+		}
+		return rssFeed, feedFormatRSS, nil
+	case "feed":
+		rssFeed, err := parseAtomFeed(dat)
+		return rssFeed, feedFormatAtom, err
+	default:
+		return RSSFeed{}, "", errUnsupportedFeed
+	}
+}
+
+func xmlRootName(dat []byte) (string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(dat))
+	decoder.Strict = false
+	for {
+		tok, err := decoder.Token()
+		if err == io.EOF {
+			return "", errUnsupportedFeed
+		}
+		if err != nil {
+			return "", err
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			return start.Name.Local, nil
+		}
+	}
+}
+
+func parseAtomFeed(dat []byte) (RSSFeed, error) {
+	feed := atomFeed{}
+	err := xml.Unmarshal(dat, &feed)
+	if err != nil {
+		return RSSFeed{}, err
+	}
+
 	rssFeed := RSSFeed{}
+	rssFeed.Channel.Title = feed.Title
+	rssFeed.Channel.Link = atomAlternateLink(feed.Links)
+	rssFeed.Channel.Description = feed.Subtitle
+	rssFeed.Channel.Language = feed.Language
+	for _, entry := range feed.Entries {
+		item := RSSItem{
+			Title:       entry.Title,
+			Link:        atomAlternateLink(entry.Links),
+			Description: entry.Summary,
+			Content:     entry.Content,
+			PubDate:     entry.Published,
+		}
+		if len(entry.Authors) > 0 {
+			item.Author = entry.Authors[0].Name
+		}
+		for _, category := range entry.Categories {
+			item.Categories = append(item.Categories, category.Term)
+		}
+		if item.Description == "" {
+			item.Description = entry.Content
+		}
+		if item.PubDate == "" {
+			item.PubDate = entry.Updated
+		}
+		rssFeed.Channel.Item = append(rssFeed.Channel.Item, item)
+	}
+	return rssFeed, nil
+}
+
+func atomAlternateLink(links []atomLink) string {
+	for _, link := range links {
+		if link.Rel == "" || link.Rel == "alternate" {
+			return link.Href
+		}
+	}
+	return ""
+}
 
-	err = xml.Unmarshal(dat, &rssFeed)
+func parseJSONFeed(dat []byte) (RSSFeed, error) {
+	feed := jsonFeed{}
+	err := json.Unmarshal(dat, &feed)
 	if err != nil {
 		return RSSFeed{}, err
 	}
+	if !strings.Contains(feed.Version, "jsonfeed.org") {
+		return RSSFeed{}, errUnsupportedFeed
+	}
 
+	rssFeed := RSSFeed{}
+	rssFeed.Channel.Title = feed.Title
+	rssFeed.Channel.Link = feed.HomePageURL
+	rssFeed.Channel.Description = feed.Description
+	rssFeed.Channel.Language = feed.Language
+	for _, entry := range feed.Items {
+		item := RSSItem{
+			Title:       entry.Title,
+			Link:        entry.URL,
+			Description: entry.Summary,
+			Content:     entry.ContentHTML,
+			PubDate:     entry.DatePublished,
+			Categories:  entry.Tags,
+		}
+		if len(entry.Authors) > 0 {
+			item.Author = entry.Authors[0].Name
+		} else if entry.Author != nil {
+			item.Author = entry.Author.Name
+		}
+		if item.Content == "" {
+			item.Content = entry.ContentText
+		}
+		if item.Description == "" {
+			item.Description = entry.ContentHTML
+		}
+		if item.Description == "" {
+			item.Description = entry.ContentText
+		}
+		if item.PubDate == "" {
+			item.PubDate = entry.DateModified
+		}
+		rssFeed.Channel.Item = append(rssFeed.Channel.Item, item)
+	}
 	return rssFeed, nil
 }
@@ -14,9 +14,11 @@ import (
 )
 
 func startScrapping(
+	conn *sql.DB,
 	db *database.Queries,
 	concurrency int,
 	timeBetweenRequest time.Duration,
+	postRetention time.Duration,
 ) {
 	log.Printf("Scraping on %v goroutines every %s duration", concurrency, timeBetweenRequest)
 
@@ -35,7 +37,7 @@ func startScrapping(
 		for _, feed := range feeds {
 			wg.Add(1)
 
-			go scrapeFeed(db, wg, feed)
+			go scrapeFeed(conn, db, wg, feed, postRetention)
 		}
 		wg.Wait()
 	}
@@ -66,7 +68,7 @@ func parseTime(value string) (time.Time, error) {
 	return time.Time{}, fmt.Errorf("could not parse time: %q, last error: %v", value, err)
 }
 
-func scrapeFeed(db *database.Queries, wg *sync.WaitGroup, feed database.Feed) {
+func scrapeFeed(conn *sql.DB, db *database.Queries, wg *sync.WaitGroup, feed database.Feed, postRetention time.Duration) {
 	defer wg.Done()
 
 	_, err := db.MarkFeedAsFetched(context.Background(), feed.ID)
@@ -81,6 +83,42 @@ func scrapeFeed(db *database.Queries, wg *sync.WaitGroup, feed database.Feed) {
 		return
 	}
 
+	// The language decides how new posts are indexed for search, so it has
+	// to be stored before they are inserted.
+	if rssFeed.Channel.Language != "" {
+		err = db.SetFeedLanguage(context.Background(), database.SetFeedLanguageParams{
+			ID:       feed.ID,
+			Language: sql.NullString{String: rssFeed.Channel.Language, Valid: true},
+		})
+		if err != nil {
+			log.Printf("Failed to set language for feed %s: %v", feed.Name, err)
+		}
+	}
+
+	if siteURL := resolveSiteURL(feed.Url, rssFeed.Channel.Link); siteURL != "" {
+		err = db.SetFeedSiteURL(context.Background(), database.SetFeedSiteURLParams{
+			ID:      feed.ID,
+			SiteUrl: sql.NullString{String: siteURL, Valid: true},
+		})
+		if err != nil {
+			log.Printf("Failed to set site URL for feed %s: %v", feed.Name, err)
+		}
+	}
+
+	rules := []filterRule{}
+	dbRules, err := db.GetFilterRulesForFeed(context.Background(), feed.ID)
+	if err != nil {
+		log.Printf("Failed to get filter rules for feed %s: %v", feed.Name, err)
+	}
+	for _, dbRule := range dbRules {
+		rule, err := compileFilterRule(dbRule)
+		if err != nil {
+			log.Printf("Skipping filter rule %s: %v", dbRule.ID, err)
+			continue
+		}
+		rules = append(rules, rule)
+	}
+
 	for _, item := range rssFeed.Channel.Item {
 		description := sql.NullString{}
 		if item.Description != "" {
@@ -88,13 +126,30 @@ func scrapeFeed(db *database.Queries, wg *sync.WaitGroup, feed database.Feed) {
 			description.Valid = true
 		}
 
+		content := sql.NullString{}
+		if item.Content != "" {
+			content.String = item.Content
+			content.Valid = true
+		}
+
+		author := sql.NullString{}
+		if item.Author != "" {
+			author.String = strings.TrimSpace(item.Author)
+			author.Valid = true
+		}
+
 		pubAt, err := parseTime(item.PubDate)
 		if err != nil {
 			log.Printf("Failed to parse time: %v", err)
 			continue
 		}
 
-		_, err = db.CreatePost(context.Background(), database.CreatePostParams{
+		// Don't bring back posts the retention cleanup already pruned.
+		if postRetention > 0 && pubAt.Before(time.Now().Add(-postRetention)) {
+			continue
+		}
+
+		post, err := db.CreatePost(context.Background(), database.CreatePostParams{
 			ID:          uuid.New(),
 			CreatedAt:   time.Now().UTC(),
 			UpdatedAt:   time.Now().UTC(),
@@ -103,12 +158,31 @@ func scrapeFeed(db *database.Queries, wg *sync.WaitGroup, feed database.Feed) {
 			PublishedAt: pubAt.UTC(),
 			Url:         item.Link,
 			FeedID:      feed.ID,
+			Content:     content,
+			Author:      author,
+			Categories:  postCategories(item.Categories),
 		})
 		if err != nil {
 			if strings.Contains(err.Error(), "unique constraint") {
 				continue
 			}
 			log.Printf("Failed to create post for feed %s: %v", feed.Name, err)
+			continue
+		}
+
+		_, err = db.MatchSavedSearchesForPost(context.Background(), post.ID)
+		if err != nil {
+			log.Printf("Failed to match saved searches for post %s: %v", post.ID, err)
+		}
+
+		for _, rule := range rules {
+			if !rule.matches(post) {
+				continue
+			}
+			_, err = applyFilterRule(context.Background(), conn, db, rule, post.ID)
+			if err != nil {
+				log.Printf("Failed to apply filter rule %s to post %s: %v", rule.ID, post.ID, err)
+			}
 		}
 	}
 	log.Printf("Feed %s collected, %v posts found", feed.Name, len(rssFeed.Channel.Item))
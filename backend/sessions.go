@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Jayant-Verma/rssagg/internal/auth"
+	"github.com/Jayant-Verma/rssagg/internal/database"
+	"github.com/google/uuid"
+)
+
+// tokenPair is what a login or a refresh hands to the client.
+type tokenPair struct {
+	Token                 string    `json:"token"`
+	ExpiresAt             time.Time `json:"expires_at"`
+	RefreshToken          string    `json:"refresh_token"`
+	RefreshTokenExpiresAt time.Time `json:"refresh_token_expires_at"`
+	CSRFToken             string    `json:"csrf_token"`
+}
+
+// maxUserAgentLength caps the User-Agent stored with a session.
+const maxUserAgentLength = 512
+
+// startSession records a new login for user from the device making r and
+// issues its first tokens.
+func (apiCfg *apiConfig) startSession(r *http.Request, user database.User) (tokenPair, error) {
+	ctx := r.Context()
+	tx, err := apiCfg.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return tokenPair{}, err
+	}
+	defer tx.Rollback()
+	qtx := apiCfg.DB.WithTx(tx)
+
+	session, err := qtx.CreateSession(ctx, database.CreateSessionParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now().UTC(),
+		UserID:    user.ID,
+		ExpiresAt: time.Now().UTC().Add(apiCfg.RefreshTokenTTL),
+		UserAgent: truncateRunes(r.UserAgent(), maxUserAgentLength),
+		Ip:        apiCfg.clientIP(r),
+	})
+	if err != nil {
+		return tokenPair{}, err
+	}
+
+	tokens, err := apiCfg.issueTokens(ctx, qtx, session)
+	if err != nil {
+		return tokenPair{}, err
+	}
+
+	return tokens, tx.Commit()
+}
+
+// issueTokens creates the next refresh token of session, pushing the end of
+// the session back to its expiry, along with a new access token.
+func (apiCfg *apiConfig) issueTokens(ctx context.Context, qtx *database.Queries, session database.Session) (tokenPair, error) {
+	refreshToken, err := auth.GenerateToken()
+	if err != nil {
+		return tokenPair{}, err
+	}
+
+	stored, err := qtx.CreateRefreshToken(ctx, database.CreateRefreshTokenParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now().UTC(),
+		SessionID: session.ID,
+		TokenHash: auth.HashToken(refreshToken),
+		ExpiresAt: time.Now().UTC().Add(apiCfg.RefreshTokenTTL),
+	})
+	if err != nil {
+		return tokenPair{}, err
+	}
+
+	err = qtx.ExtendSession(ctx, database.ExtendSessionParams{
+		ID:        session.ID,
+		ExpiresAt: stored.ExpiresAt,
+	})
+	if err != nil {
+		return tokenPair{}, err
+	}
+
+	expiresAt := time.Now().UTC().Add(apiCfg.AccessTokenTTL)
+	token, err := auth.GetJWT(session.UserID, session.ID, apiCfg.AccessTokenTTL)
+	if err != nil {
+		return tokenPair{}, err
+	}
+
+	return tokenPair{
+		Token:                 token,
+		ExpiresAt:             expiresAt,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: stored.ExpiresAt,
+		CSRFToken:             csrfToken(session.ID),
+	}, nil
+}
+
+// clientIP is the address a request came from. The client named by a
+// reverse proxy in X-Forwarded-For or X-Real-IP is only believed when the
+// request comes from one of apiCfg.TrustedProxies, since anyone can send
+// those headers.
+func (apiCfg *apiConfig) clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if !apiCfg.isTrustedProxy(net.ParseIP(host)) {
+		return host
+	}
+
+	// Each proxy appends the address it got the request from, so the client
+	// is the last address not added by one of our own proxies.
+	if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+		hops := strings.Split(strings.Join(forwarded, ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			ip := net.ParseIP(strings.TrimSpace(hops[i]))
+			if ip == nil {
+				break
+			}
+			if i == 0 || !apiCfg.isTrustedProxy(ip) {
+				return ip.String()
+			}
+		}
+	}
+	if ip := net.ParseIP(strings.TrimSpace(r.Header.Get("X-Real-IP"))); ip != nil {
+		return ip.String()
+	}
+	return host
+}
+
+func (apiCfg *apiConfig) isTrustedProxy(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	for _, proxy := range apiCfg.TrustedProxies {
+		if proxy.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
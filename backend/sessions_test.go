@@ -0,0 +1,62 @@
+package main
+
+import (
+	"net"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	_, proxies, _ := net.ParseCIDR("10.0.0.0/8")
+	apiCfg := &apiConfig{TrustedProxies: []*net.IPNet{proxies}}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string]string
+		want       string
+	}{
+		{
+			name:       "direct connection",
+			remoteAddr: "203.0.113.7:4321",
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "forwarded header from an untrusted client",
+			remoteAddr: "203.0.113.7:4321",
+			headers:    map[string]string{"X-Forwarded-For": "198.51.100.1", "X-Real-IP": "198.51.100.2"},
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "forwarded header from a trusted proxy",
+			remoteAddr: "10.0.0.2:4321",
+			headers:    map[string]string{"X-Forwarded-For": "198.51.100.1"},
+			want:       "198.51.100.1",
+		},
+		{
+			name:       "spoofed hop before the real client",
+			remoteAddr: "10.0.0.2:4321",
+			headers:    map[string]string{"X-Forwarded-For": "192.0.2.9, 198.51.100.1, 10.0.0.3"},
+			want:       "198.51.100.1",
+		},
+		{
+			name:       "real IP header from a trusted proxy",
+			remoteAddr: "10.0.0.2:4321",
+			headers:    map[string]string{"X-Real-IP": "198.51.100.2"},
+			want:       "198.51.100.2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/v1/sessions", nil)
+			r.RemoteAddr = tt.remoteAddr
+			for key, value := range tt.headers {
+				r.Header.Set(key, value)
+			}
+			if got := apiCfg.clientIP(r); got != tt.want {
+				t.Errorf("clientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+-- name: UpsertPostNote :one
+INSERT INTO post_notes (user_id, post_id, created_at, updated_at, body)
+VALUES ($1, $2, NOW(), NOW(), $3)
+ON CONFLICT (user_id, post_id) DO UPDATE
+SET body = EXCLUDED.body,
+updated_at = NOW()
+RETURNING *;
+
+-- name: GetPostNote :one
+SELECT * FROM post_notes WHERE user_id = $1 AND post_id = $2;
+
+-- name: DeletePostNote :execrows
+DELETE FROM post_notes WHERE user_id = $1 AND post_id = $2;
+
+-- name: CreatePostHighlight :one
+INSERT INTO post_highlights (id, created_at, updated_at, user_id, post_id, quote, start_offset, end_offset, comment)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING *;
+
+-- name: GetPostHighlights :many
+SELECT * FROM post_highlights
+WHERE user_id = $1 AND post_id = $2
+ORDER BY start_offset ASC NULLS LAST, created_at ASC;
+
+-- name: UpdatePostHighlightComment :one
+UPDATE post_highlights
+SET comment = $3,
+updated_at = NOW()
+WHERE id = $1 AND user_id = $2
+RETURNING *;
+
+-- name: DeletePostHighlight :execrows
+DELETE FROM post_highlights WHERE id = $1 AND user_id = $2;
+
+-- name: GetAnnotatedPostsForUser :many
+SELECT posts.*, feeds.name AS feed_name, annotated.last_annotated_at::timestamp AS last_annotated_at
+FROM (
+    SELECT annotations.post_id, MAX(annotations.updated_at) AS last_annotated_at
+    FROM (
+        SELECT post_notes.post_id, post_notes.updated_at FROM post_notes WHERE post_notes.user_id = $1
+        UNION ALL
+        SELECT post_highlights.post_id, post_highlights.updated_at FROM post_highlights WHERE post_highlights.user_id = $1
+    ) AS annotations
+    GROUP BY annotations.post_id
+) AS annotated
+JOIN posts ON posts.id = annotated.post_id
+JOIN feeds ON feeds.id = posts.feed_id
+ORDER BY annotated.last_annotated_at DESC;
+
+-- name: GetNotesForUser :many
+SELECT * FROM post_notes WHERE user_id = $1;
+
+-- name: GetHighlightsForUser :many
+SELECT * FROM post_highlights
+WHERE user_id = $1
+ORDER BY post_id, start_offset ASC NULLS LAST, created_at ASC;
@@ -3,8 +3,59 @@ INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id)
 VALUES ($1, $2, $3, $4, $5)
 RETURNING *;
 
+-- name: FollowFeed :one
+INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (user_id, feed_id) DO UPDATE SET updated_at = feed_follows.updated_at
+RETURNING *;
+
 -- name: GetFeedFollows :many
 SELECT * FROM feed_follows WHERE user_id = $1;
 
 -- name: DeleteFeedFollow :exec
-DELETE FROM feed_follows WHERE feed_id = $1 AND user_id = $2;
\ No newline at end of file
+DELETE FROM feed_follows WHERE feed_id = $1 AND user_id = $2;
+
+-- name: GetEarliestOtherFollower :one
+SELECT user_id FROM feed_follows
+WHERE feed_id = $1 AND user_id <> $2
+ORDER BY created_at ASC
+LIMIT 1;
+
+
+-- name: GetFeedFollowsWithFeeds :many
+SELECT feed_follows.*, feeds.name AS feed_name, feeds.url AS feed_url, feeds.paused AS feed_paused
+FROM feed_follows
+JOIN feeds ON feeds.id = feed_follows.feed_id
+WHERE feed_follows.user_id = $1
+ORDER BY feed_follows.priority DESC, COALESCE(feed_follows.title, feeds.name) ASC;
+
+-- name: UpdateFeedFollowSettings :one
+UPDATE feed_follows
+SET title = $3,
+priority = $4,
+muted = $5,
+show_in_timeline = $6,
+notifications = $7,
+updated_at = NOW()
+WHERE id = $1 AND user_id = $2
+RETURNING *;
+
+-- name: GetFeedFollowByID :one
+SELECT * FROM feed_follows WHERE id = $1 AND user_id = $2;
+
+-- name: SetFeedFollowFolder :one
+UPDATE feed_follows
+SET folder_id = $3,
+updated_at = NOW()
+WHERE id = $1 AND user_id = $2
+RETURNING *;
+
+-- name: GetFeedFollowsForExport :many
+SELECT feed_follows.folder_id, COALESCE(feed_follows.title, feeds.name) AS title, feeds.url, feeds.site_url
+FROM feed_follows
+JOIN feeds ON feeds.id = feed_follows.feed_id
+WHERE feed_follows.user_id = $1
+ORDER BY title ASC;
+
+-- name: DeleteFeedFollowByID :execrows
+DELETE FROM feed_follows WHERE id = $1 AND user_id = $2;
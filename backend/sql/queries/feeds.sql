@@ -3,11 +3,18 @@ INSERT INTO feeds (id, created_at, updated_at, name, url, user_id)
 VALUES ($1, $2, $3, $4, $5, $6)
 RETURNING *;
 
+-- name: GetFeedByID :one
+SELECT * FROM feeds WHERE id = $1;
+
+-- name: GetFeedByURL :one
+SELECT * FROM feeds WHERE url = $1;
+
 -- name: GetFeeds :many
 SELECT * FROM feeds;
 
 -- name: GetNextFeedsToFetchs :many
 SELECT * FROM feeds 
+WHERE NOT paused
 ORDER BY last_fetched_at ASC NULLS FIRST
 LIMIT $1;
 
@@ -16,4 +23,66 @@ UPDATE feeds
 SET last_fetched_at = NOW(),
 updated_at = NOW()
 WHERE id = $1
-RETURNING *;
\ No newline at end of file
+RETURNING *;
+
+-- name: SetFeedLanguage :exec
+UPDATE feeds
+SET language = $2
+WHERE id = $1 AND language IS DISTINCT FROM $2;
+
+-- name: SetFeedSiteURL :exec
+UPDATE feeds
+SET site_url = $2
+WHERE id = $1 AND site_url IS DISTINCT FROM $2;
+
+-- name: UpdateFeed :one
+UPDATE feeds
+SET name = $2,
+url = $3,
+paused = $4,
+last_fetched_at = $5,
+updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: TransferFeedOwnership :one
+UPDATE feeds
+SET user_id = $2,
+updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteFeed :exec
+DELETE FROM feeds WHERE id = $1;
+
+-- name: DeleteUnfollowedFeeds :execrows
+DELETE FROM feeds
+WHERE created_at < $1
+AND NOT EXISTS (
+    SELECT 1 FROM feed_follows WHERE feed_follows.feed_id = feeds.id
+)
+AND NOT EXISTS (
+    SELECT 1 FROM posts
+    JOIN post_states ON post_states.post_id = posts.id
+    WHERE posts.feed_id = feeds.id AND post_states.starred
+)
+AND NOT EXISTS (
+    SELECT 1 FROM posts
+    WHERE posts.feed_id = feeds.id AND (
+        EXISTS (SELECT 1 FROM post_notes WHERE post_notes.post_id = posts.id)
+        OR EXISTS (SELECT 1 FROM post_highlights WHERE post_highlights.post_id = posts.id)
+    )
+);
+
+-- name: TransferOwnedFeeds :execrows
+UPDATE feeds
+SET user_id = successors.user_id,
+updated_at = NOW()
+FROM (
+    SELECT DISTINCT ON (feed_follows.feed_id) feed_follows.feed_id, feed_follows.user_id
+    FROM feed_follows
+    JOIN feeds ON feeds.id = feed_follows.feed_id
+    WHERE feeds.user_id = $1 AND feed_follows.user_id <> $1
+    ORDER BY feed_follows.feed_id, feed_follows.created_at ASC
+) AS successors
+WHERE feeds.id = successors.feed_id;
@@ -0,0 +1,61 @@
+-- name: CreateFilterRule :one
+INSERT INTO filter_rules (id, created_at, updated_at, user_id, feed_id, name, match_field, match_type, pattern, action, tag, enabled)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+RETURNING *;
+
+-- name: GetFilterRules :many
+SELECT filter_rules.*, (
+    SELECT COUNT(*) FROM filter_rule_matches
+    WHERE filter_rule_matches.filter_rule_id = filter_rules.id
+) AS match_count
+FROM filter_rules
+WHERE filter_rules.user_id = $1
+ORDER BY filter_rules.created_at ASC;
+
+-- name: GetFilterRuleByID :one
+SELECT * FROM filter_rules WHERE id = $1 AND user_id = $2;
+
+-- name: UpdateFilterRule :one
+UPDATE filter_rules
+SET feed_id = $3,
+name = $4,
+match_field = $5,
+match_type = $6,
+pattern = $7,
+action = $8,
+tag = $9,
+enabled = $10,
+updated_at = NOW()
+WHERE id = $1 AND user_id = $2
+RETURNING *;
+
+-- name: DeleteFilterRule :execrows
+DELETE FROM filter_rules WHERE id = $1 AND user_id = $2;
+
+-- name: GetFilterRulesForFeed :many
+SELECT filter_rules.* FROM filter_rules
+JOIN feed_follows ON feed_follows.user_id = filter_rules.user_id AND feed_follows.feed_id = $1
+WHERE filter_rules.enabled
+AND (filter_rules.feed_id IS NULL OR filter_rules.feed_id = $1)
+ORDER BY filter_rules.created_at ASC;
+
+-- name: RecordFilterRuleMatch :execrows
+INSERT INTO filter_rule_matches (filter_rule_id, post_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: CountFilterRuleMatches :one
+SELECT COUNT(*) FROM filter_rule_matches WHERE filter_rule_id = $1;
+
+-- name: ClearFilterRuleMatches :exec
+DELETE FROM filter_rule_matches WHERE filter_rule_id = $1;
+
+-- name: GetPostsForFilterRule :many
+SELECT posts.* FROM posts
+JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+WHERE feed_follows.user_id = sqlc.arg('user_id')
+AND (sqlc.narg('feed_id')::uuid IS NULL OR posts.feed_id = sqlc.narg('feed_id'))
+AND (sqlc.narg('before_published_at')::timestamp IS NULL
+    OR (posts.published_at, posts.id) < (sqlc.narg('before_published_at'), sqlc.narg('before_id')::uuid))
+ORDER BY posts.published_at DESC, posts.id DESC
+LIMIT sqlc.arg('limit');
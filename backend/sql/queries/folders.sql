@@ -0,0 +1,28 @@
+-- name: CreateFolder :one
+INSERT INTO folders (id, created_at, updated_at, user_id, parent_id, name)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetFolders :many
+SELECT * FROM folders WHERE user_id = $1 ORDER BY name ASC;
+
+-- name: GetFolderByID :one
+SELECT * FROM folders WHERE id = $1 AND user_id = $2;
+
+-- name: CountChildFolders :one
+SELECT COUNT(*) FROM folders WHERE parent_id = $1;
+
+-- name: UpdateFolder :one
+UPDATE folders
+SET name = $3,
+parent_id = $4,
+updated_at = NOW()
+WHERE id = $1 AND user_id = $2
+RETURNING *;
+
+-- name: DeleteFolder :execrows
+DELETE FROM folders WHERE id = $1 AND user_id = $2;
+
+-- name: GetFolderByName :one
+SELECT * FROM folders
+WHERE user_id = $1 AND parent_id IS NOT DISTINCT FROM $2 AND name = $3;
@@ -0,0 +1,39 @@
+-- name: CreateOPMLImport :one
+INSERT INTO opml_imports (id, created_at, updated_at, user_id, total)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: CreateOPMLImportItem :exec
+INSERT INTO opml_import_items (id, import_id, position, title, url, parent_folder, folder)
+VALUES ($1, $2, $3, $4, $5, $6, $7);
+
+-- name: GetOPMLImport :one
+SELECT * FROM opml_imports WHERE id = $1 AND user_id = $2;
+
+-- name: GetOPMLImportItems :many
+SELECT * FROM opml_import_items WHERE import_id = $1 ORDER BY position ASC;
+
+-- name: GetPendingOPMLImportItems :many
+SELECT * FROM opml_import_items
+WHERE import_id = $1 AND status = 'pending'
+ORDER BY position ASC;
+
+-- name: GetUnfinishedOPMLImports :many
+SELECT * FROM opml_imports
+WHERE status IN ('pending', 'running')
+ORDER BY created_at ASC;
+
+-- name: SetOPMLImportStatus :exec
+UPDATE opml_imports
+SET status = $2,
+error = $3,
+finished_at = CASE WHEN $2 IN ('done', 'failed') THEN NOW() END,
+updated_at = NOW()
+WHERE id = $1;
+
+-- name: SetOPMLImportItemResult :exec
+UPDATE opml_import_items
+SET status = $2,
+feed_id = $3,
+error = $4
+WHERE id = $1;
@@ -0,0 +1,73 @@
+-- name: SetPostRead :execrows
+INSERT INTO post_states (user_id, post_id, feed_id, created_at, updated_at, read, read_at)
+SELECT feed_follows.user_id, posts.id, posts.feed_id, NOW(), NOW(), sqlc.arg('read')::boolean,
+    CASE WHEN sqlc.arg('read')::boolean THEN NOW() END
+FROM posts
+JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+WHERE posts.id = sqlc.arg('post_id') AND feed_follows.user_id = sqlc.arg('user_id')
+ON CONFLICT (user_id, post_id) DO UPDATE
+SET read = EXCLUDED.read,
+read_at = CASE WHEN post_states.read AND EXCLUDED.read THEN post_states.read_at ELSE EXCLUDED.read_at END,
+updated_at = NOW();
+
+-- name: MarkPostsRead :execrows
+INSERT INTO post_states (user_id, post_id, feed_id, created_at, updated_at, read, read_at)
+SELECT feed_follows.user_id, posts.id, posts.feed_id, NOW(), NOW(), true, NOW()
+FROM posts
+JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+WHERE feed_follows.user_id = sqlc.arg('user_id')
+AND (sqlc.narg('feed_id')::uuid IS NULL OR posts.feed_id = sqlc.narg('feed_id'))
+AND (sqlc.narg('folder_id')::uuid IS NULL OR feed_follows.folder_id IN (
+    SELECT folders.id FROM folders
+    WHERE folders.id = sqlc.narg('folder_id') OR folders.parent_id = sqlc.narg('folder_id')
+))
+AND (sqlc.narg('before')::timestamp IS NULL OR posts.published_at < sqlc.narg('before'))
+ON CONFLICT (user_id, post_id) DO UPDATE
+SET read = true,
+read_at = EXCLUDED.read_at,
+updated_at = NOW()
+WHERE NOT post_states.read;
+
+
+-- name: GetUnreadCountsForUser :many
+SELECT feed_follows.id, feed_follows.feed_id, feed_follows.folder_id, feed_follows.muted,
+    (COALESCE(feed_post_counts.post_count, 0) - COALESCE(read_counts.read, 0))::bigint AS unread
+FROM feed_follows
+LEFT JOIN feed_post_counts ON feed_post_counts.feed_id = feed_follows.feed_id
+LEFT JOIN (
+    SELECT post_states.feed_id, COUNT(*) AS read
+    FROM post_states
+    WHERE post_states.user_id = $1 AND post_states.read
+    GROUP BY post_states.feed_id
+) read_counts ON read_counts.feed_id = feed_follows.feed_id
+WHERE feed_follows.user_id = $1;
+
+-- name: StarPost :execrows
+INSERT INTO post_states (user_id, post_id, feed_id, created_at, updated_at, starred, starred_at)
+SELECT feed_follows.user_id, posts.id, posts.feed_id, NOW(), NOW(), true, NOW()
+FROM posts
+JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+WHERE posts.id = $1 AND feed_follows.user_id = $2
+ON CONFLICT (user_id, post_id) DO UPDATE
+SET starred = true,
+starred_at = COALESCE(post_states.starred_at, EXCLUDED.starred_at),
+updated_at = NOW();
+
+-- name: UnstarPost :execrows
+UPDATE post_states
+SET starred = false,
+starred_at = NULL,
+updated_at = NOW()
+WHERE post_id = $1 AND user_id = $2;
+
+-- name: HidePost :execrows
+INSERT INTO post_states (user_id, post_id, feed_id, created_at, updated_at, read, read_at, hidden)
+SELECT feed_follows.user_id, posts.id, posts.feed_id, NOW(), NOW(), true, NOW(), true
+FROM posts
+JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+WHERE posts.id = $1 AND feed_follows.user_id = $2
+ON CONFLICT (user_id, post_id) DO UPDATE
+SET hidden = true,
+read = true,
+read_at = COALESCE(post_states.read_at, EXCLUDED.read_at),
+updated_at = NOW();
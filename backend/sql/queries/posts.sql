@@ -1,11 +1,162 @@
 -- name: CreatePost :one
-INSERT INTO posts (id, created_at, updated_at, title, description, published_at, url, feed_id)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+INSERT INTO posts (id, created_at, updated_at, title, description, published_at, url, feed_id, content, author, categories)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 RETURNING *;
 
+-- name: GetPostForUser :one
+SELECT posts.* FROM posts
+WHERE posts.id = $1
+AND (
+    EXISTS (
+        SELECT 1 FROM feed_follows
+        WHERE feed_follows.feed_id = posts.feed_id AND feed_follows.user_id = $2
+    )
+    OR EXISTS (
+        SELECT 1 FROM post_states
+        WHERE post_states.post_id = posts.id AND post_states.user_id = $2
+    )
+);
+
 -- name: GetPostsForUser :many
-SELECT posts.* FROM posts 
+SELECT posts.*, COALESCE(post_states.read, false) AS read, post_states.read_at,
+    COALESCE(post_states.starred, false) AS starred, post_states.starred_at
+FROM posts 
+JOIN feed_follows ON posts.feed_id = feed_follows.feed_id
+LEFT JOIN post_states ON post_states.post_id = posts.id AND post_states.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = sqlc.arg('user_id')
+AND NOT COALESCE(post_states.hidden, false)
+AND (sqlc.narg('feed_id')::uuid IS NOT NULL OR sqlc.narg('tag')::text IS NOT NULL OR NOT feed_follows.muted)
+AND (sqlc.narg('feed_id')::uuid IS NOT NULL OR sqlc.narg('folder_id')::uuid IS NOT NULL
+    OR sqlc.narg('tag')::text IS NOT NULL OR feed_follows.show_in_timeline)
+AND (sqlc.narg('feed_id')::uuid IS NULL OR posts.feed_id = sqlc.narg('feed_id'))
+AND (sqlc.narg('folder_id')::uuid IS NULL OR feed_follows.folder_id IN (
+    SELECT folders.id FROM folders
+    WHERE folders.id = sqlc.narg('folder_id') OR folders.parent_id = sqlc.narg('folder_id')
+))
+AND (sqlc.narg('since')::timestamp IS NULL OR posts.published_at >= sqlc.narg('since'))
+AND (sqlc.narg('until')::timestamp IS NULL OR posts.published_at < sqlc.narg('until'))
+AND (sqlc.narg('read')::boolean IS NULL OR COALESCE(post_states.read, false) = sqlc.narg('read'))
+AND (sqlc.narg('before_published_at')::timestamp IS NULL
+    OR (posts.published_at, posts.id) < (sqlc.narg('before_published_at'), sqlc.narg('before_id')::uuid))
+AND (sqlc.narg('tag')::text IS NULL OR EXISTS (
+    SELECT 1 FROM post_tags
+    JOIN tags ON tags.id = post_tags.tag_id
+    WHERE post_tags.post_id = posts.id AND tags.user_id = sqlc.arg('user_id') AND tags.name = sqlc.narg('tag')
+))
+ORDER BY posts.published_at DESC, posts.id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetPostsForUserOldestFirst :many
+SELECT posts.*, COALESCE(post_states.read, false) AS read, post_states.read_at,
+    COALESCE(post_states.starred, false) AS starred, post_states.starred_at
+FROM posts 
 JOIN feed_follows ON posts.feed_id = feed_follows.feed_id
-WHERE feed_follows.user_id = $1
-ORDER BY published_at DESC
-LIMIT $2;
\ No newline at end of file
+LEFT JOIN post_states ON post_states.post_id = posts.id AND post_states.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = sqlc.arg('user_id')
+AND NOT COALESCE(post_states.hidden, false)
+AND (sqlc.narg('feed_id')::uuid IS NOT NULL OR sqlc.narg('tag')::text IS NOT NULL OR NOT feed_follows.muted)
+AND (sqlc.narg('feed_id')::uuid IS NOT NULL OR sqlc.narg('folder_id')::uuid IS NOT NULL
+    OR sqlc.narg('tag')::text IS NOT NULL OR feed_follows.show_in_timeline)
+AND (sqlc.narg('feed_id')::uuid IS NULL OR posts.feed_id = sqlc.narg('feed_id'))
+AND (sqlc.narg('folder_id')::uuid IS NULL OR feed_follows.folder_id IN (
+    SELECT folders.id FROM folders
+    WHERE folders.id = sqlc.narg('folder_id') OR folders.parent_id = sqlc.narg('folder_id')
+))
+AND (sqlc.narg('since')::timestamp IS NULL OR posts.published_at >= sqlc.narg('since'))
+AND (sqlc.narg('until')::timestamp IS NULL OR posts.published_at < sqlc.narg('until'))
+AND (sqlc.narg('read')::boolean IS NULL OR COALESCE(post_states.read, false) = sqlc.narg('read'))
+AND (sqlc.narg('after_published_at')::timestamp IS NULL
+    OR (posts.published_at, posts.id) > (sqlc.narg('after_published_at'), sqlc.narg('after_id')::uuid))
+AND (sqlc.narg('tag')::text IS NULL OR EXISTS (
+    SELECT 1 FROM post_tags
+    JOIN tags ON tags.id = post_tags.tag_id
+    WHERE post_tags.post_id = posts.id AND tags.user_id = sqlc.arg('user_id') AND tags.name = sqlc.narg('tag')
+))
+ORDER BY posts.published_at ASC, posts.id ASC
+LIMIT sqlc.arg('limit');
+
+-- name: GetStarredPostsForUser :many
+SELECT posts.*, post_states.read, post_states.read_at, post_states.starred_at
+FROM post_states
+JOIN posts ON posts.id = post_states.post_id
+LEFT JOIN feed_follows ON feed_follows.feed_id = posts.feed_id AND feed_follows.user_id = post_states.user_id
+WHERE post_states.user_id = sqlc.arg('user_id')
+AND post_states.starred
+AND (sqlc.narg('feed_id')::uuid IS NULL OR posts.feed_id = sqlc.narg('feed_id'))
+AND (sqlc.narg('folder_id')::uuid IS NULL OR feed_follows.folder_id IN (
+    SELECT folders.id FROM folders
+    WHERE folders.id = sqlc.narg('folder_id') OR folders.parent_id = sqlc.narg('folder_id')
+))
+AND (sqlc.narg('since')::timestamp IS NULL OR posts.published_at >= sqlc.narg('since'))
+AND (sqlc.narg('until')::timestamp IS NULL OR posts.published_at < sqlc.narg('until'))
+AND (sqlc.narg('read')::boolean IS NULL OR COALESCE(post_states.read, false) = sqlc.narg('read'))
+AND (sqlc.narg('before_published_at')::timestamp IS NULL
+    OR (posts.published_at, posts.id) < (sqlc.narg('before_published_at'), sqlc.narg('before_id')::uuid))
+AND (sqlc.narg('tag')::text IS NULL OR EXISTS (
+    SELECT 1 FROM post_tags
+    JOIN tags ON tags.id = post_tags.tag_id
+    WHERE post_tags.post_id = posts.id AND tags.user_id = sqlc.arg('user_id') AND tags.name = sqlc.narg('tag')
+))
+ORDER BY posts.published_at DESC, posts.id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetStarredPostsForUserOldestFirst :many
+SELECT posts.*, post_states.read, post_states.read_at, post_states.starred_at
+FROM post_states
+JOIN posts ON posts.id = post_states.post_id
+LEFT JOIN feed_follows ON feed_follows.feed_id = posts.feed_id AND feed_follows.user_id = post_states.user_id
+WHERE post_states.user_id = sqlc.arg('user_id')
+AND post_states.starred
+AND (sqlc.narg('feed_id')::uuid IS NULL OR posts.feed_id = sqlc.narg('feed_id'))
+AND (sqlc.narg('folder_id')::uuid IS NULL OR feed_follows.folder_id IN (
+    SELECT folders.id FROM folders
+    WHERE folders.id = sqlc.narg('folder_id') OR folders.parent_id = sqlc.narg('folder_id')
+))
+AND (sqlc.narg('since')::timestamp IS NULL OR posts.published_at >= sqlc.narg('since'))
+AND (sqlc.narg('until')::timestamp IS NULL OR posts.published_at < sqlc.narg('until'))
+AND (sqlc.narg('read')::boolean IS NULL OR COALESCE(post_states.read, false) = sqlc.narg('read'))
+AND (sqlc.narg('after_published_at')::timestamp IS NULL
+    OR (posts.published_at, posts.id) > (sqlc.narg('after_published_at'), sqlc.narg('after_id')::uuid))
+AND (sqlc.narg('tag')::text IS NULL OR EXISTS (
+    SELECT 1 FROM post_tags
+    JOIN tags ON tags.id = post_tags.tag_id
+    WHERE post_tags.post_id = posts.id AND tags.user_id = sqlc.arg('user_id') AND tags.name = sqlc.narg('tag')
+))
+ORDER BY posts.published_at ASC, posts.id ASC
+LIMIT sqlc.arg('limit');
+
+-- name: SearchPostsForUser :many
+SELECT posts.*, COALESCE(post_states.read, false) AS read, post_states.read_at,
+    COALESCE(post_states.starred, false) AS starred, post_states.starred_at,
+    ts_rank(post_search.document, search.query)::real AS rank,
+    ts_headline(post_search.config, escape_html(posts.title), search.query,
+        'HighlightAll=true, StartSel=<mark>, StopSel=</mark>') AS title_highlight,
+    ts_headline(post_search.config, escape_html(strip_html(COALESCE(NULLIF(posts.content, ''), posts.description))), search.query,
+        'StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=30, MinWords=10') AS snippet
+FROM post_search
+JOIN posts ON posts.id = post_search.post_id
+JOIN feed_follows ON feed_follows.feed_id = posts.feed_id AND feed_follows.user_id = sqlc.arg('user_id')
+LEFT JOIN post_states ON post_states.post_id = posts.id AND post_states.user_id = feed_follows.user_id
+CROSS JOIN (
+    SELECT websearch_to_tsquery('simple', sqlc.arg('query'))
+        || websearch_to_tsquery(search_config(sqlc.arg('lang')), sqlc.arg('query')) AS query
+) AS search
+WHERE post_search.document @@ search.query
+AND NOT COALESCE(post_states.hidden, false)
+AND (sqlc.narg('feed_id')::uuid IS NULL OR posts.feed_id = sqlc.narg('feed_id'))
+AND (sqlc.narg('folder_id')::uuid IS NULL OR feed_follows.folder_id IN (
+    SELECT folders.id FROM folders
+    WHERE folders.id = sqlc.narg('folder_id') OR folders.parent_id = sqlc.narg('folder_id')
+))
+ORDER BY rank DESC, posts.published_at DESC, posts.id DESC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: DeleteOldPosts :execrows
+DELETE FROM posts
+WHERE published_at < $1
+AND NOT EXISTS (
+    SELECT 1 FROM post_states
+    WHERE post_states.post_id = posts.id AND post_states.starred
+)
+AND NOT EXISTS (SELECT 1 FROM post_notes WHERE post_notes.post_id = posts.id)
+AND NOT EXISTS (SELECT 1 FROM post_highlights WHERE post_highlights.post_id = posts.id);
@@ -0,0 +1,108 @@
+-- name: CreateSavedSearch :one
+INSERT INTO saved_searches (id, created_at, updated_at, user_id, name, query, lang)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: GetSavedSearches :many
+SELECT * FROM saved_searches WHERE user_id = $1 ORDER BY name ASC;
+
+-- name: GetSavedSearchByID :one
+SELECT * FROM saved_searches WHERE id = $1 AND user_id = $2;
+
+-- name: UpdateSavedSearch :one
+UPDATE saved_searches
+SET name = $3,
+query = $4,
+lang = $5,
+updated_at = NOW()
+WHERE id = $1 AND user_id = $2
+RETURNING *;
+
+-- name: DeleteSavedSearch :execrows
+DELETE FROM saved_searches WHERE id = $1 AND user_id = $2;
+
+-- name: ClearSavedSearchMatches :exec
+DELETE FROM saved_search_matches WHERE saved_search_id = $1;
+
+-- name: BackfillSavedSearch :execrows
+INSERT INTO saved_search_matches (saved_search_id, post_id, created_at)
+SELECT saved_searches.id, posts.id, NOW()
+FROM saved_searches
+JOIN feed_follows ON feed_follows.user_id = saved_searches.user_id
+JOIN posts ON posts.feed_id = feed_follows.feed_id
+JOIN post_search ON post_search.post_id = posts.id
+WHERE saved_searches.id = $1
+AND post_search.document @@ (
+    websearch_to_tsquery('simple', saved_searches.query)
+    || websearch_to_tsquery(search_config(saved_searches.lang), saved_searches.query)
+)
+ON CONFLICT DO NOTHING;
+
+-- name: BackfillSavedSearchesForFeed :execrows
+INSERT INTO saved_search_matches (saved_search_id, post_id, created_at)
+SELECT saved_searches.id, posts.id, NOW()
+FROM saved_searches
+JOIN posts ON posts.feed_id = $2
+JOIN post_search ON post_search.post_id = posts.id
+WHERE saved_searches.user_id = $1
+AND post_search.document @@ (
+    websearch_to_tsquery('simple', saved_searches.query)
+    || websearch_to_tsquery(search_config(saved_searches.lang), saved_searches.query)
+)
+ON CONFLICT DO NOTHING;
+
+-- name: MatchSavedSearchesForPost :execrows
+INSERT INTO saved_search_matches (saved_search_id, post_id, created_at)
+SELECT saved_searches.id, posts.id, NOW()
+FROM posts
+JOIN post_search ON post_search.post_id = posts.id
+JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+JOIN saved_searches ON saved_searches.user_id = feed_follows.user_id
+WHERE posts.id = $1
+AND post_search.document @@ (
+    websearch_to_tsquery('simple', saved_searches.query)
+    || websearch_to_tsquery(search_config(saved_searches.lang), saved_searches.query)
+)
+ON CONFLICT DO NOTHING;
+
+-- name: GetSavedSearchPosts :many
+SELECT posts.*, COALESCE(post_states.read, false) AS read, post_states.read_at,
+    COALESCE(post_states.starred, false) AS starred, post_states.starred_at
+FROM saved_search_matches
+JOIN posts ON posts.id = saved_search_matches.post_id
+JOIN feed_follows ON feed_follows.feed_id = posts.feed_id AND feed_follows.user_id = sqlc.arg('user_id')
+LEFT JOIN post_states ON post_states.post_id = posts.id AND post_states.user_id = feed_follows.user_id
+WHERE saved_search_matches.saved_search_id = sqlc.arg('saved_search_id')
+AND NOT COALESCE(post_states.hidden, false)
+AND (sqlc.narg('read')::boolean IS NULL OR COALESCE(post_states.read, false) = sqlc.narg('read'))
+AND (sqlc.narg('before_published_at')::timestamp IS NULL
+    OR (posts.published_at, posts.id) < (sqlc.narg('before_published_at'), sqlc.narg('before_id')::uuid))
+ORDER BY posts.published_at DESC, posts.id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetSavedSearchPostsOldestFirst :many
+SELECT posts.*, COALESCE(post_states.read, false) AS read, post_states.read_at,
+    COALESCE(post_states.starred, false) AS starred, post_states.starred_at
+FROM saved_search_matches
+JOIN posts ON posts.id = saved_search_matches.post_id
+JOIN feed_follows ON feed_follows.feed_id = posts.feed_id AND feed_follows.user_id = sqlc.arg('user_id')
+LEFT JOIN post_states ON post_states.post_id = posts.id AND post_states.user_id = feed_follows.user_id
+WHERE saved_search_matches.saved_search_id = sqlc.arg('saved_search_id')
+AND NOT COALESCE(post_states.hidden, false)
+AND (sqlc.narg('read')::boolean IS NULL OR COALESCE(post_states.read, false) = sqlc.narg('read'))
+AND (sqlc.narg('after_published_at')::timestamp IS NULL
+    OR (posts.published_at, posts.id) > (sqlc.narg('after_published_at'), sqlc.narg('after_id')::uuid))
+ORDER BY posts.published_at ASC, posts.id ASC
+LIMIT sqlc.arg('limit');
+
+-- name: GetSavedSearchUnreadCounts :many
+SELECT saved_searches.id,
+    COUNT(feed_follows.id) FILTER (WHERE NOT COALESCE(post_states.read, false)) AS unread
+FROM saved_searches
+LEFT JOIN saved_search_matches ON saved_search_matches.saved_search_id = saved_searches.id
+LEFT JOIN posts ON posts.id = saved_search_matches.post_id
+LEFT JOIN feed_follows ON feed_follows.feed_id = posts.feed_id AND feed_follows.user_id = saved_searches.user_id
+LEFT JOIN post_states ON post_states.post_id = posts.id AND post_states.user_id = saved_searches.user_id
+WHERE saved_searches.user_id = $1
+GROUP BY saved_searches.id
+ORDER BY saved_searches.name ASC;
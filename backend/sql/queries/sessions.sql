@@ -0,0 +1,45 @@
+-- name: CreateSession :one
+INSERT INTO sessions (id, created_at, user_id, expires_at, user_agent, ip, last_seen_at)
+VALUES ($1, $2, $3, $4, $5, $6, $2)
+RETURNING *;
+
+-- name: GetSessionByID :one
+SELECT * FROM sessions WHERE id = $1;
+
+-- name: ExtendSession :exec
+UPDATE sessions SET expires_at = $2 WHERE id = $1;
+
+-- name: RevokeSession :exec
+UPDATE sessions SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL;
+
+-- name: GetActiveSessionsForUser :many
+SELECT * FROM sessions
+WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+ORDER BY last_seen_at DESC;
+
+-- name: TouchSession :exec
+UPDATE sessions
+SET last_seen_at = NOW(), ip = $2
+WHERE id = $1 AND last_seen_at < NOW() - INTERVAL '1 minute';
+
+-- name: RevokeSessionForUser :execrows
+UPDATE sessions SET revoked_at = NOW()
+WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL;
+
+-- name: RevokeSessionsForUser :execrows
+UPDATE sessions SET revoked_at = NOW()
+WHERE user_id = $1 AND revoked_at IS NULL AND id <> $2;
+
+-- name: DeleteExpiredSessions :execrows
+DELETE FROM sessions WHERE expires_at < $1;
+
+-- name: CreateRefreshToken :one
+INSERT INTO refresh_tokens (id, created_at, session_id, token_hash, expires_at)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetRefreshTokenByHash :one
+SELECT * FROM refresh_tokens WHERE token_hash = $1;
+
+-- name: UseRefreshToken :execrows
+UPDATE refresh_tokens SET used_at = NOW() WHERE id = $1 AND used_at IS NULL;
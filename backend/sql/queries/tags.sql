@@ -0,0 +1,40 @@
+-- name: UpsertTag :one
+INSERT INTO tags (id, created_at, user_id, name)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (user_id, name) DO UPDATE
+SET name = EXCLUDED.name
+RETURNING *;
+
+-- name: TagPost :exec
+INSERT INTO post_tags (tag_id, post_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: UntagPost :execrows
+DELETE FROM post_tags
+USING tags
+WHERE post_tags.tag_id = tags.id
+AND post_tags.tag_id = $1 AND post_tags.post_id = $2 AND tags.user_id = $3;
+
+-- name: GetTagsWithCounts :many
+SELECT tags.*, COUNT(post_tags.post_id) AS post_count
+FROM tags
+LEFT JOIN post_tags ON post_tags.tag_id = tags.id
+WHERE tags.user_id = $1
+GROUP BY tags.id
+ORDER BY tags.name ASC;
+
+-- name: GetTagsForPost :many
+SELECT tags.* FROM tags
+JOIN post_tags ON post_tags.tag_id = tags.id
+WHERE tags.user_id = $1 AND post_tags.post_id = $2
+ORDER BY tags.name ASC;
+
+-- name: RenameTag :one
+UPDATE tags
+SET name = $3
+WHERE id = $1 AND user_id = $2
+RETURNING *;
+
+-- name: DeleteTag :execrows
+DELETE FROM tags WHERE id = $1 AND user_id = $2;
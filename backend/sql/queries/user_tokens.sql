@@ -0,0 +1,18 @@
+-- name: CreateUserToken :one
+INSERT INTO user_tokens (id, created_at, user_id, purpose, token_hash, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetUserTokenByHash :one
+SELECT * FROM user_tokens WHERE token_hash = $1 AND purpose = $2;
+
+-- name: UseUserToken :execrows
+UPDATE user_tokens SET used_at = NOW()
+WHERE id = $1 AND used_at IS NULL AND expires_at > NOW();
+
+-- name: InvalidateUserTokens :exec
+UPDATE user_tokens SET used_at = NOW()
+WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL;
+
+-- name: DeleteExpiredUserTokens :execrows
+DELETE FROM user_tokens WHERE expires_at < $1;
@@ -1,15 +1,56 @@
 -- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, name, email, password_hash, api_key) 
-VALUES ($1, $2, $3, $4, $5, $6,
-    encode(sha256(random()::text::bytea), 'hex')
-)
+INSERT INTO users (id, created_at, updated_at, name, email, password_hash)
+VALUES ($1, $2, $3, $4, $5, $6)
 RETURNING *;
 
--- name: GetUserByAPIKey :one
-SELECT * FROM users WHERE api_key = $1;
+-- name: GetUserByAPIKeyHash :one
+SELECT * FROM users WHERE api_key_hash = $1;
 
 -- name: GetUserByEmail :one
 SELECT * FROM users WHERE email = $1;
 
 -- name: GetUserByID :one
-SELECT * FROM users WHERE id = $1;
\ No newline at end of file
+SELECT * FROM users WHERE id = $1;
+
+-- name: GetUserByFeedTokenHash :one
+SELECT * FROM users WHERE feed_token_hash = $1;
+
+-- name: SetUserFeedTokenHash :one
+UPDATE users
+SET feed_token_hash = $2,
+updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: SetUserAPIKeyHash :one
+UPDATE users
+SET api_key_hash = $2,
+updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: SetUserPasswordHash :one
+UPDATE users
+SET password_hash = $2,
+updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: SetUserEmailVerified :one
+UPDATE users
+SET email_verified_at = NOW(),
+updated_at = NOW()
+WHERE id = $1 AND email_verified_at IS NULL
+RETURNING *;
+
+-- name: UpdateUserProfile :one
+UPDATE users
+SET name = $2,
+email = $3,
+email_verified_at = CASE WHEN email = $3 THEN email_verified_at END,
+updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteUser :execrows
+DELETE FROM users WHERE id = $1;
@@ -0,0 +1,14 @@
+-- +goose Up
+ALTER TABLE feed_follows ADD COLUMN title TEXT;
+ALTER TABLE feed_follows ADD COLUMN priority INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE feed_follows ADD COLUMN muted BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE feed_follows ADD COLUMN show_in_timeline BOOLEAN NOT NULL DEFAULT true;
+ALTER TABLE feed_follows ADD COLUMN notifications TEXT NOT NULL DEFAULT 'none'
+    CHECK (notifications IN ('none', 'digest', 'instant'));
+
+-- +goose Down
+ALTER TABLE feed_follows DROP COLUMN notifications;
+ALTER TABLE feed_follows DROP COLUMN show_in_timeline;
+ALTER TABLE feed_follows DROP COLUMN muted;
+ALTER TABLE feed_follows DROP COLUMN priority;
+ALTER TABLE feed_follows DROP COLUMN title;
@@ -0,0 +1,21 @@
+-- +goose Up
+CREATE TABLE folders (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    parent_id UUID REFERENCES folders(id) ON DELETE CASCADE,
+    name TEXT NOT NULL
+);
+
+CREATE UNIQUE INDEX folders_user_parent_name_idx ON folders (
+    user_id,
+    COALESCE(parent_id, '00000000-0000-0000-0000-000000000000'),
+    name
+);
+
+ALTER TABLE feed_follows ADD COLUMN folder_id UUID REFERENCES folders(id) ON DELETE SET NULL;
+
+-- +goose Down
+ALTER TABLE feed_follows DROP COLUMN folder_id;
+DROP TABLE folders;
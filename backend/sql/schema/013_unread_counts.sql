@@ -0,0 +1,12 @@
+-- +goose Up
+ALTER TABLE post_states ADD COLUMN feed_id UUID REFERENCES feeds(id) ON DELETE CASCADE;
+UPDATE post_states SET feed_id = posts.feed_id FROM posts WHERE posts.id = post_states.post_id;
+ALTER TABLE post_states ALTER COLUMN feed_id SET NOT NULL;
+
+CREATE INDEX posts_feed_id_published_at_idx ON posts (feed_id, published_at DESC);
+CREATE INDEX post_states_user_feed_read_idx ON post_states (user_id, feed_id) WHERE read;
+
+-- +goose Down
+DROP INDEX post_states_user_feed_read_idx;
+DROP INDEX posts_feed_id_published_at_idx;
+ALTER TABLE post_states DROP COLUMN feed_id;
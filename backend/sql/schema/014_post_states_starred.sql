@@ -0,0 +1,10 @@
+-- +goose Up
+ALTER TABLE post_states ADD COLUMN starred BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE post_states ADD COLUMN starred_at TIMESTAMP;
+
+CREATE INDEX post_states_user_starred_idx ON post_states (user_id) WHERE starred;
+
+-- +goose Down
+DROP INDEX post_states_user_starred_idx;
+ALTER TABLE post_states DROP COLUMN starred_at;
+ALTER TABLE post_states DROP COLUMN starred;
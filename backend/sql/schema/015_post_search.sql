@@ -0,0 +1,91 @@
+-- +goose Up
+ALTER TABLE posts ADD COLUMN content TEXT;
+ALTER TABLE feeds ADD COLUMN language TEXT;
+
+-- search_config maps a feed's language tag (en, en-US, de_DE, ...) to the
+-- text search configuration used to stem its posts.
+-- +goose StatementBegin
+CREATE FUNCTION search_config(lang TEXT) RETURNS regconfig AS $$
+    SELECT CASE lower(split_part(replace(COALESCE(lang, ''), '_', '-'), '-', 1))
+        WHEN 'da' THEN 'danish'
+        WHEN 'de' THEN 'german'
+        WHEN 'en' THEN 'english'
+        WHEN 'es' THEN 'spanish'
+        WHEN 'fi' THEN 'finnish'
+        WHEN 'fr' THEN 'french'
+        WHEN 'hu' THEN 'hungarian'
+        WHEN 'it' THEN 'italian'
+        WHEN 'nl' THEN 'dutch'
+        WHEN 'no' THEN 'norwegian'
+        WHEN 'nb' THEN 'norwegian'
+        WHEN 'pt' THEN 'portuguese'
+        WHEN 'ro' THEN 'romanian'
+        WHEN 'ru' THEN 'russian'
+        WHEN 'sv' THEN 'swedish'
+        WHEN 'tr' THEN 'turkish'
+        ELSE 'simple'
+    END::regconfig
+$$ LANGUAGE SQL IMMUTABLE;
+-- +goose StatementEnd
+
+-- The document holds both the stemmed words for the feed's language and the
+-- words as written, so searches without a language still find every post.
+CREATE TABLE post_search (
+    post_id UUID PRIMARY KEY REFERENCES posts(id) ON DELETE CASCADE,
+    config regconfig NOT NULL,
+    document tsvector NOT NULL
+);
+
+CREATE INDEX post_search_document_idx ON post_search USING GIN (document);
+
+-- +goose StatementBegin
+CREATE FUNCTION strip_html(html TEXT) RETURNS TEXT AS $$
+    SELECT regexp_replace(COALESCE(html, ''), '<[^>]*>', ' ', 'g')
+$$ LANGUAGE SQL IMMUTABLE;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE FUNCTION post_search_document(cfg regconfig, title TEXT, description TEXT, content TEXT) RETURNS tsvector AS $$
+    SELECT setweight(to_tsvector(cfg, COALESCE(title, '')), 'A')
+        || setweight(to_tsvector(cfg, strip_html(description)), 'B')
+        || setweight(to_tsvector(cfg, strip_html(content)), 'C')
+        || setweight(to_tsvector('simple', COALESCE(title, '')), 'A')
+        || setweight(to_tsvector('simple', strip_html(description)), 'B')
+        || setweight(to_tsvector('simple', strip_html(content)), 'C')
+$$ LANGUAGE SQL IMMUTABLE;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE FUNCTION posts_index_search() RETURNS trigger AS $$
+DECLARE
+    cfg regconfig;
+BEGIN
+    SELECT search_config(feeds.language) INTO cfg FROM feeds WHERE feeds.id = NEW.feed_id;
+    cfg := COALESCE(cfg, 'simple'::regconfig);
+
+    INSERT INTO post_search (post_id, config, document)
+    VALUES (NEW.id, cfg, post_search_document(cfg, NEW.title, NEW.description, NEW.content))
+    ON CONFLICT (post_id) DO UPDATE
+    SET config = EXCLUDED.config, document = EXCLUDED.document;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER posts_index_search
+AFTER INSERT OR UPDATE OF title, description, content ON posts
+FOR EACH ROW EXECUTE FUNCTION posts_index_search();
+
+INSERT INTO post_search (post_id, config, document)
+SELECT posts.id, 'simple'::regconfig, post_search_document('simple', posts.title, posts.description, posts.content)
+FROM posts;
+
+-- +goose Down
+DROP TRIGGER posts_index_search ON posts;
+DROP FUNCTION posts_index_search();
+DROP TABLE post_search;
+DROP FUNCTION post_search_document(regconfig, TEXT, TEXT, TEXT);
+DROP FUNCTION strip_html(TEXT);
+DROP FUNCTION search_config(TEXT);
+ALTER TABLE feeds DROP COLUMN language;
+ALTER TABLE posts DROP COLUMN content;
@@ -0,0 +1,38 @@
+-- +goose Up
+ALTER TABLE posts ADD COLUMN author TEXT;
+-- categories holds one category per line.
+ALTER TABLE posts ADD COLUMN categories TEXT;
+
+ALTER TABLE post_states ADD COLUMN hidden BOOLEAN NOT NULL DEFAULT false;
+
+CREATE TABLE filter_rules (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    feed_id UUID REFERENCES feeds(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    match_field TEXT NOT NULL CHECK (match_field IN ('title', 'content', 'text', 'author', 'category')),
+    match_type TEXT NOT NULL CHECK (match_type IN ('keyword', 'regex')),
+    pattern TEXT NOT NULL,
+    action TEXT NOT NULL CHECK (action IN ('hide', 'mark_read', 'star', 'tag')),
+    tag TEXT,
+    enabled BOOLEAN NOT NULL DEFAULT true,
+    CHECK ((action = 'tag') = (tag IS NOT NULL))
+);
+
+CREATE INDEX filter_rules_user_id_idx ON filter_rules (user_id);
+
+CREATE TABLE filter_rule_matches (
+    filter_rule_id UUID NOT NULL REFERENCES filter_rules(id) ON DELETE CASCADE,
+    post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (filter_rule_id, post_id)
+);
+
+-- +goose Down
+DROP TABLE filter_rule_matches;
+DROP TABLE filter_rules;
+ALTER TABLE post_states DROP COLUMN hidden;
+ALTER TABLE posts DROP COLUMN categories;
+ALTER TABLE posts DROP COLUMN author;
@@ -0,0 +1,33 @@
+-- +goose Up
+CREATE TABLE post_notes (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    body TEXT NOT NULL,
+    PRIMARY KEY (user_id, post_id)
+);
+
+-- Offsets are character positions in the post's text as the client showed
+-- it; they are optional since the quote alone is enough to find the passage.
+CREATE TABLE post_highlights (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    quote TEXT NOT NULL,
+    start_offset INTEGER,
+    end_offset INTEGER,
+    comment TEXT,
+    CHECK (
+        (start_offset IS NULL AND end_offset IS NULL)
+        OR (start_offset >= 0 AND end_offset > start_offset)
+    )
+);
+
+CREATE INDEX post_highlights_user_post_idx ON post_highlights (user_id, post_id);
+
+-- +goose Down
+DROP TABLE post_highlights;
+DROP TABLE post_notes;
@@ -0,0 +1,35 @@
+-- +goose Up
+CREATE TABLE opml_imports (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status TEXT NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'running', 'done', 'failed')),
+    total INTEGER NOT NULL,
+    error TEXT,
+    finished_at TIMESTAMP
+);
+
+CREATE INDEX opml_imports_user_id_idx ON opml_imports (user_id);
+
+-- One row per feed outline in the file. parent_folder and folder hold the
+-- names of the enclosing outlines, since folders only nest one level deep.
+CREATE TABLE opml_import_items (
+    id UUID PRIMARY KEY,
+    import_id UUID NOT NULL REFERENCES opml_imports(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    title TEXT NOT NULL,
+    url TEXT NOT NULL,
+    parent_folder TEXT,
+    folder TEXT,
+    status TEXT NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'created', 'existing', 'failed')),
+    feed_id UUID REFERENCES feeds(id) ON DELETE SET NULL,
+    error TEXT,
+    UNIQUE(import_id, position)
+);
+
+-- +goose Down
+DROP TABLE opml_import_items;
+DROP TABLE opml_imports;
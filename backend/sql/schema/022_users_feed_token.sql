@@ -0,0 +1,9 @@
+-- +goose Up
+-- feed_token is the secret in the URLs of a user's output feeds, which feed
+-- readers fetch without logging in.
+ALTER TABLE users ADD COLUMN feed_token VARCHAR(64) UNIQUE NOT NULL DEFAULT (
+    encode(sha256(random()::text::bytea), 'hex')
+);
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN feed_token;
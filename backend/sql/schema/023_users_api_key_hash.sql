@@ -0,0 +1,16 @@
+-- +goose Up
+-- API keys are only shown once when generated; the database keeps their
+-- SHA-256 hash. Existing keys are hashed in place so they keep working.
+ALTER TABLE users RENAME COLUMN api_key TO api_key_hash;
+ALTER TABLE users ALTER COLUMN api_key_hash DROP DEFAULT;
+ALTER TABLE users ALTER COLUMN api_key_hash DROP NOT NULL;
+UPDATE users SET api_key_hash = encode(sha256(convert_to(api_key_hash, 'UTF8')), 'hex');
+
+-- +goose Down
+-- Hashed keys can't be recovered, so every user gets a new key.
+UPDATE users SET api_key_hash = encode(sha256(random()::text::bytea), 'hex');
+ALTER TABLE users ALTER COLUMN api_key_hash SET NOT NULL;
+ALTER TABLE users ALTER COLUMN api_key_hash SET DEFAULT (
+    encode(sha256(random()::text::bytea), 'hex')
+);
+ALTER TABLE users RENAME COLUMN api_key_hash TO api_key;
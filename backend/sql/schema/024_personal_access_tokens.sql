@@ -0,0 +1,18 @@
+-- +goose Up
+-- scopes is a space-separated list, as in OAuth.
+CREATE TABLE personal_access_tokens (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    token_hash VARCHAR(64) UNIQUE NOT NULL,
+    scopes TEXT NOT NULL,
+    expires_at TIMESTAMP,
+    last_used_at TIMESTAMP,
+    revoked_at TIMESTAMP
+);
+
+CREATE INDEX personal_access_tokens_user_id_idx ON personal_access_tokens (user_id);
+
+-- +goose Down
+DROP TABLE personal_access_tokens;
@@ -0,0 +1,28 @@
+-- +goose Up
+-- A session is one login. Access tokens name their session so that revoking
+-- it locks them out before they expire; refresh tokens are single use and
+-- each exchange issues the next one in the same session.
+CREATE TABLE sessions (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP
+);
+
+CREATE INDEX sessions_user_id_idx ON sessions (user_id);
+
+CREATE TABLE refresh_tokens (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    session_id UUID NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) UNIQUE NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP
+);
+
+CREATE INDEX refresh_tokens_session_id_idx ON refresh_tokens (session_id);
+
+-- +goose Down
+DROP TABLE refresh_tokens;
+DROP TABLE sessions;
@@ -0,0 +1,17 @@
+-- +goose Up
+-- Where each session was started from and when it was last used, so users
+-- can recognise their logins.
+ALTER TABLE sessions
+    ADD COLUMN user_agent TEXT NOT NULL DEFAULT '',
+    ADD COLUMN ip TEXT NOT NULL DEFAULT '',
+    ADD COLUMN last_seen_at TIMESTAMP;
+
+UPDATE sessions SET last_seen_at = created_at;
+
+ALTER TABLE sessions ALTER COLUMN last_seen_at SET NOT NULL;
+
+-- +goose Down
+ALTER TABLE sessions
+    DROP COLUMN last_seen_at,
+    DROP COLUMN ip,
+    DROP COLUMN user_agent;
@@ -0,0 +1,20 @@
+-- +goose Up
+-- Single-use tokens mailed to users, hashed like the other secrets. purpose
+-- keeps a password reset link from verifying an email and vice versa.
+CREATE TABLE user_tokens (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    purpose TEXT NOT NULL CHECK (purpose IN ('password_reset', 'email_verification')),
+    token_hash VARCHAR(64) UNIQUE NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP
+);
+
+CREATE INDEX user_tokens_user_id_idx ON user_tokens (user_id);
+
+ALTER TABLE users ADD COLUMN email_verified_at TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN email_verified_at;
+DROP TABLE user_tokens;
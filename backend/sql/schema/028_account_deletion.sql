@@ -0,0 +1,12 @@
+-- +goose Up
+-- Deleting an account takes a short-lived confirmation token, issued after
+-- the user re-enters their password.
+ALTER TABLE user_tokens DROP CONSTRAINT user_tokens_purpose_check;
+ALTER TABLE user_tokens ADD CONSTRAINT user_tokens_purpose_check
+    CHECK (purpose IN ('password_reset', 'email_verification', 'account_deletion'));
+
+-- +goose Down
+DELETE FROM user_tokens WHERE purpose = 'account_deletion';
+ALTER TABLE user_tokens DROP CONSTRAINT user_tokens_purpose_check;
+ALTER TABLE user_tokens ADD CONSTRAINT user_tokens_purpose_check
+    CHECK (purpose IN ('password_reset', 'email_verification'));
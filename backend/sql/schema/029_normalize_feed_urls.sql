@@ -0,0 +1,83 @@
+-- +goose Up
+-- Feeds added before URLs were normalized may be stored under a different
+-- spelling of a URL another feed already has. Bring them to the form
+-- normalizeFeedURL produces (lowercase scheme and host, no trailing dot,
+-- default port or fragment, "/" for an empty path) and merge feeds that end
+-- up with the same URL into the oldest of them. Query strings are kept as
+-- they are.
+CREATE TEMPORARY TABLE feed_url_parts AS
+SELECT id, created_at, url,
+    lower(m[1]) AS scheme,
+    regexp_replace(lower(m[2]), '\.(:[0-9]+)?$', '\1') AS host,
+    m[3] AS rest
+FROM (
+    SELECT id, created_at, url, regexp_match(url, '^([A-Za-z][A-Za-z0-9+.-]*)://([^/?#]*)([^#]*)') AS m
+    FROM feeds
+) AS matched
+WHERE m IS NOT NULL;
+
+CREATE TEMPORARY TABLE feed_url_merges AS
+SELECT id, normalized,
+    first_value(id) OVER (PARTITION BY normalized ORDER BY created_at, id) AS keeper_id
+FROM (
+    SELECT id, created_at,
+        scheme || '://' ||
+        CASE
+            WHEN scheme = 'http' THEN regexp_replace(host, ':80$', '')
+            WHEN scheme = 'https' THEN regexp_replace(host, ':443$', '')
+            ELSE host
+        END ||
+        CASE WHEN rest = '' OR rest LIKE '?%' THEN '/' || rest ELSE rest END AS normalized
+    FROM feed_url_parts
+) AS normalized_urls;
+
+-- Followers of a duplicate follow the kept feed instead, once per user.
+UPDATE feed_follows
+SET feed_id = feed_url_merges.keeper_id,
+updated_at = NOW()
+FROM feed_url_merges
+WHERE feed_follows.feed_id = feed_url_merges.id
+AND feed_url_merges.id <> feed_url_merges.keeper_id
+AND NOT EXISTS (
+    SELECT 1 FROM feed_follows AS kept
+    WHERE kept.user_id = feed_follows.user_id AND kept.feed_id = feed_url_merges.keeper_id
+)
+AND feed_follows.id IN (
+    SELECT DISTINCT ON (moved.user_id, merges.keeper_id) moved.id
+    FROM feed_follows AS moved
+    JOIN feed_url_merges AS merges ON merges.id = moved.feed_id
+    WHERE merges.id <> merges.keeper_id
+    ORDER BY moved.user_id, merges.keeper_id, moved.created_at
+);
+
+UPDATE posts SET feed_id = feed_url_merges.keeper_id
+FROM feed_url_merges
+WHERE posts.feed_id = feed_url_merges.id AND feed_url_merges.id <> feed_url_merges.keeper_id;
+
+UPDATE post_states SET feed_id = feed_url_merges.keeper_id
+FROM feed_url_merges
+WHERE post_states.feed_id = feed_url_merges.id AND feed_url_merges.id <> feed_url_merges.keeper_id;
+
+UPDATE filter_rules SET feed_id = feed_url_merges.keeper_id
+FROM feed_url_merges
+WHERE filter_rules.feed_id = feed_url_merges.id AND feed_url_merges.id <> feed_url_merges.keeper_id;
+
+UPDATE opml_import_items SET feed_id = feed_url_merges.keeper_id
+FROM feed_url_merges
+WHERE opml_import_items.feed_id = feed_url_merges.id AND feed_url_merges.id <> feed_url_merges.keeper_id;
+
+DELETE FROM feeds
+USING feed_url_merges
+WHERE feeds.id = feed_url_merges.id AND feed_url_merges.id <> feed_url_merges.keeper_id;
+
+UPDATE feeds SET url = feed_url_merges.normalized
+FROM feed_url_merges
+WHERE feeds.id = feed_url_merges.id AND feeds.url <> feed_url_merges.normalized;
+
+DROP TABLE feed_url_merges;
+DROP TABLE feed_url_parts;
+
+-- +goose Down
+-- The original spellings and merged feeds are not kept, so there is nothing
+-- to undo.
+SELECT 1;
@@ -0,0 +1,6 @@
+-- +goose Up
+-- Post listings page through (published_at, id) in either direction.
+CREATE INDEX posts_published_at_id_idx ON posts (published_at, id);
+
+-- +goose Down
+DROP INDEX posts_published_at_id_idx;
@@ -0,0 +1,12 @@
+-- +goose Up
+-- escape_html makes feed text safe to show as HTML, so the <mark> tags
+-- search highlights add are the only markup in them.
+-- +goose StatementBegin
+CREATE FUNCTION escape_html(t TEXT) RETURNS TEXT AS $$
+    SELECT replace(replace(replace(replace(replace(COALESCE(t, ''),
+        '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&quot;'), '''', '&#39;')
+$$ LANGUAGE SQL IMMUTABLE;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP FUNCTION escape_html(TEXT);
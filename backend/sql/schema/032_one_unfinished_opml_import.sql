@@ -0,0 +1,21 @@
+-- +goose Up
+-- A user may have only one import queued or running at a time. Extra
+-- unfinished imports from before this rule are failed, oldest kept.
+UPDATE opml_imports
+SET status = 'failed',
+error = 'Another import was already in progress',
+finished_at = NOW(),
+updated_at = NOW()
+WHERE status IN ('pending', 'running')
+AND EXISTS (
+    SELECT 1 FROM opml_imports AS older
+    WHERE older.user_id = opml_imports.user_id
+    AND older.status IN ('pending', 'running')
+    AND (older.created_at, older.id) < (opml_imports.created_at, opml_imports.id)
+);
+
+CREATE UNIQUE INDEX opml_imports_one_unfinished_idx ON opml_imports (user_id)
+WHERE status IN ('pending', 'running');
+
+-- +goose Down
+DROP INDEX opml_imports_one_unfinished_idx;
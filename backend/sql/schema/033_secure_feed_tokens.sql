@@ -0,0 +1,12 @@
+-- +goose Up
+-- random() is not a cryptographic generator, so feed tokens made by the old
+-- default could be guessed. New ones come from pgcrypto and every existing
+-- token is replaced.
+CREATE EXTENSION IF NOT EXISTS pgcrypto;
+
+ALTER TABLE users ALTER COLUMN feed_token SET DEFAULT encode(gen_random_bytes(32), 'hex');
+
+UPDATE users SET feed_token = encode(gen_random_bytes(32), 'hex');
+
+-- +goose Down
+ALTER TABLE users ALTER COLUMN feed_token SET DEFAULT encode(sha256(random()::text::bytea), 'hex');
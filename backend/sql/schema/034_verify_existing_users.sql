@@ -0,0 +1,14 @@
+-- +goose Up
+-- Accounts created before email verification existed never got a
+-- verification email, so they count as verified. goose_db_version records
+-- when 027_user_tokens.sql ran; users.created_at is stored in UTC.
+UPDATE users SET email_verified_at = created_at
+WHERE email_verified_at IS NULL
+AND created_at < (
+    SELECT MIN(tstamp) AT TIME ZONE current_setting('TimeZone') AT TIME ZONE 'UTC'
+    FROM goose_db_version
+    WHERE version_id = 27
+);
+
+-- +goose Down
+SELECT 1;
@@ -0,0 +1,51 @@
+-- +goose Up
+-- Unread counts subtract a user's read posts from these totals instead of
+-- counting every post of every followed feed on each request.
+CREATE TABLE feed_post_counts (
+    feed_id UUID PRIMARY KEY REFERENCES feeds(id) ON DELETE CASCADE,
+    post_count BIGINT NOT NULL DEFAULT 0
+);
+
+-- +goose StatementBegin
+CREATE FUNCTION posts_count_inserted() RETURNS trigger AS $$
+BEGIN
+    INSERT INTO feed_post_counts (feed_id, post_count)
+    SELECT feed_id, COUNT(*) FROM inserted_posts GROUP BY feed_id
+    ON CONFLICT (feed_id) DO UPDATE
+    SET post_count = feed_post_counts.post_count + EXCLUDED.post_count;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE FUNCTION posts_count_deleted() RETURNS trigger AS $$
+BEGIN
+    UPDATE feed_post_counts
+    SET post_count = feed_post_counts.post_count - deleted.count
+    FROM (SELECT feed_id, COUNT(*) AS count FROM deleted_posts GROUP BY feed_id) deleted
+    WHERE feed_post_counts.feed_id = deleted.feed_id;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER posts_count_inserted
+AFTER INSERT ON posts
+REFERENCING NEW TABLE AS inserted_posts
+FOR EACH STATEMENT EXECUTE FUNCTION posts_count_inserted();
+
+CREATE TRIGGER posts_count_deleted
+AFTER DELETE ON posts
+REFERENCING OLD TABLE AS deleted_posts
+FOR EACH STATEMENT EXECUTE FUNCTION posts_count_deleted();
+
+INSERT INTO feed_post_counts (feed_id, post_count)
+SELECT feed_id, COUNT(*) FROM posts GROUP BY feed_id;
+
+-- +goose Down
+DROP TRIGGER posts_count_deleted ON posts;
+DROP TRIGGER posts_count_inserted ON posts;
+DROP FUNCTION posts_count_deleted();
+DROP FUNCTION posts_count_inserted();
+DROP TABLE feed_post_counts;
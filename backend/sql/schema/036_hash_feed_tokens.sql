@@ -0,0 +1,16 @@
+-- +goose Up
+-- Only a hash of the feed token is kept, like API keys, so a leaked database
+-- doesn't hand out every user's output feeds. Existing URLs keep working.
+-- Users created from now on have no token, and no output feeds, until they
+-- create one.
+ALTER TABLE users ADD COLUMN feed_token_hash VARCHAR(64) UNIQUE;
+
+UPDATE users SET feed_token_hash = encode(sha256(convert_to(feed_token, 'UTF8')), 'hex');
+
+ALTER TABLE users DROP COLUMN feed_token;
+
+-- +goose Down
+-- The hashes can't be turned back into tokens, so everyone gets a new one.
+ALTER TABLE users ADD COLUMN feed_token VARCHAR(64) UNIQUE NOT NULL DEFAULT encode(gen_random_bytes(32), 'hex');
+
+ALTER TABLE users DROP COLUMN feed_token_hash;
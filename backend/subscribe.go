@@ -0,0 +1,228 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Jayant-Verma/rssagg/internal/database"
+	"github.com/google/uuid"
+)
+
+const maxFeedNameLength = 255
+
+// subscribeError is a failure caused by the submitted feed rather than by the
+// server, carrying the HTTP status it should be reported with.
+type subscribeError struct {
+	Code       int
+	Message    string
+	Candidates []FeedCandidate
+}
+
+func (e *subscribeError) Error() string {
+	return e.Message
+}
+
+func respondWithSubscribeError(w http.ResponseWriter, subErr *subscribeError) {
+	if len(subErr.Candidates) == 0 {
+		respondWithError(w, subErr.Code, subErr.Message)
+		return
+	}
+
+	type candidatesResponse struct {
+		Error      string          `json:"error"`
+		Candidates []FeedCandidate `json:"candidates"`
+	}
+	respondWithJSON(w, subErr.Code, candidatesResponse{
+		Error:      subErr.Message,
+		Candidates: subErr.Candidates,
+	})
+}
+
+// resolveFeedURL fetches feedURL, follows autodiscovery when it points at a
+// web page and checks that the result parses as a feed. It returns the
+// canonical URL of the feed along with its parsed content.
+func resolveFeedURL(ctx context.Context, feedURL string) (string, RSSFeed, error) {
+	dat, resp, err := fetchURL(ctx, feedURL)
+	if err != nil {
+		return "", RSSFeed{}, &subscribeError{Code: http.StatusBadRequest, Message: fmt.Sprintf("Failed to fetch URL: %v", err)}
+	}
+
+	if isHTML(resp, dat) {
+		candidates := discoverFeeds(ctx, resp.Request.URL, dat)
+		if len(candidates) == 0 {
+			return "", RSSFeed{}, &subscribeError{Code: http.StatusBadRequest, Message: "No feed found at URL"}
+		}
+		if len(candidates) > 1 {
+			return "", RSSFeed{}, &subscribeError{
+				Code:       http.StatusMultipleChoices,
+				Message:    "Multiple feeds found at URL, pick one of the candidates",
+				Candidates: candidates,
+			}
+		}
+
+		dat, resp, err = fetchURL(ctx, candidates[0].URL)
+		if err != nil {
+			return "", RSSFeed{}, &subscribeError{Code: http.StatusBadRequest, Message: fmt.Sprintf("Failed to fetch discovered feed: %v", err)}
+		}
+	}
+
+	rssFeed, _, err := parseFeed(dat)
+	if err != nil {
+		return "", RSSFeed{}, &subscribeError{Code: http.StatusBadRequest, Message: fmt.Sprintf("URL is not a valid feed: %v", err)}
+	}
+
+	canonicalURL, err := normalizeFeedURL(resp.Request.URL.String())
+	if err != nil {
+		return "", RSSFeed{}, &subscribeError{Code: http.StatusBadRequest, Message: err.Error()}
+	}
+
+	return canonicalURL, rssFeed, nil
+}
+
+type subscription struct {
+	Feed       database.Feed
+	FeedFollow database.FeedFollow
+	// Created is set when the feed itself is new, Followed when the user
+	// was not following it before.
+	Created  bool
+	Followed bool
+}
+
+// subscribeToFeed follows the feed at rawURL for user, creating the feed
+// first when no feed with the same canonical URL exists yet.
+func (apiCfg *apiConfig) subscribeToFeed(ctx context.Context, user database.User, name, rawURL string) (subscription, error) {
+	name = strings.TrimSpace(name)
+	if utf8.RuneCountInString(name) > maxFeedNameLength {
+		return subscription{}, &subscribeError{Code: http.StatusBadRequest, Message: fmt.Sprintf("Name must be at most %d characters", maxFeedNameLength)}
+	}
+
+	feedURL, err := normalizeFeedURL(rawURL)
+	if err != nil {
+		return subscription{}, &subscribeError{Code: http.StatusBadRequest, Message: err.Error()}
+	}
+
+	feed, err := apiCfg.DB.GetFeedByURL(ctx, feedURL)
+	if err == nil {
+		return apiCfg.followExistingFeed(ctx, user, feed)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return subscription{}, err
+	}
+
+	feedURL, rssFeed, err := resolveFeedURL(ctx, feedURL)
+	if err != nil {
+		return subscription{}, err
+	}
+
+	// Redirects and autodiscovery may have led to a feed we already have.
+	feed, err = apiCfg.DB.GetFeedByURL(ctx, feedURL)
+	if err == nil {
+		return apiCfg.followExistingFeed(ctx, user, feed)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return subscription{}, err
+	}
+
+	if name == "" {
+		name = truncateRunes(strings.TrimSpace(rssFeed.Channel.Title), maxFeedNameLength)
+	}
+	if name == "" {
+		u, _ := url.Parse(feedURL)
+		name = u.Hostname()
+	}
+
+	tx, err := apiCfg.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return subscription{}, err
+	}
+	defer tx.Rollback()
+	qtx := apiCfg.DB.WithTx(tx)
+
+	feed, err = qtx.CreateFeed(ctx, database.CreateFeedParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+		Name:      name,
+		UserID:    user.ID,
+		Url:       feedURL,
+	})
+	if isUniqueViolation(err) {
+		// Another request added the feed since we looked it up.
+		tx.Rollback()
+		feed, err = apiCfg.DB.GetFeedByURL(ctx, feedURL)
+		if err != nil {
+			return subscription{}, err
+		}
+		return apiCfg.followExistingFeed(ctx, user, feed)
+	}
+	if err != nil {
+		return subscription{}, err
+	}
+
+	feedFollow, err := qtx.FollowFeed(ctx, database.FollowFeedParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+		UserID:    user.ID,
+		FeedID:    feed.ID,
+	})
+	if err != nil {
+		return subscription{}, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return subscription{}, err
+	}
+
+	return subscription{Feed: feed, FeedFollow: feedFollow, Created: true, Followed: true}, nil
+}
+
+func (apiCfg *apiConfig) followExistingFeed(ctx context.Context, user database.User, feed database.Feed) (subscription, error) {
+	feedFollowID := uuid.New()
+	feedFollow, err := apiCfg.DB.FollowFeed(ctx, database.FollowFeedParams{
+		ID:        feedFollowID,
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+		UserID:    user.ID,
+		FeedID:    feed.ID,
+	})
+	if err != nil {
+		return subscription{}, err
+	}
+
+	// FollowFeed returns the existing row when the user already follows it.
+	followed := feedFollow.ID == feedFollowID
+	if followed {
+		apiCfg.backfillSavedSearches(ctx, user.ID, feed.ID)
+	}
+	return subscription{Feed: feed, FeedFollow: feedFollow, Followed: followed}, nil
+}
+
+// backfillSavedSearches matches the posts a feed already has against the
+// user's saved searches when they start following it. The scraper only
+// matches posts as they come in.
+func (apiCfg *apiConfig) backfillSavedSearches(ctx context.Context, userID, feedID uuid.UUID) {
+	_, err := apiCfg.DB.BackfillSavedSearchesForFeed(ctx, database.BackfillSavedSearchesForFeedParams{
+		UserID: userID,
+		FeedID: feedID,
+	})
+	if err != nil {
+		log.Printf("Failed to match saved searches of user %v against feed %v: %v", userID, feedID, err)
+	}
+}
+
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/Jayant-Verma/rssagg/internal/auth"
+	"github.com/Jayant-Verma/rssagg/internal/database"
+	"github.com/Jayant-Verma/rssagg/internal/mailer"
+	"github.com/google/uuid"
+)
+
+const (
+	userTokenPasswordReset     = "password_reset"
+	userTokenEmailVerification = "email_verification"
+	userTokenAccountDeletion   = "account_deletion"
+
+	passwordResetTTL     = time.Hour
+	emailVerificationTTL = 48 * time.Hour
+)
+
+// issueUserToken creates a single-use token for purpose, replacing any the
+// user was sent before, and returns the secret to put in the email.
+func (apiCfg *apiConfig) issueUserToken(ctx context.Context, user database.User, purpose string, ttl time.Duration) (string, error) {
+	token, err := auth.GenerateToken()
+	if err != nil {
+		return "", err
+	}
+
+	tx, err := apiCfg.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+	qtx := apiCfg.DB.WithTx(tx)
+
+	err = qtx.InvalidateUserTokens(ctx, database.InvalidateUserTokensParams{
+		UserID:  user.ID,
+		Purpose: purpose,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	_, err = qtx.CreateUserToken(ctx, database.CreateUserTokenParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now().UTC(),
+		UserID:    user.ID,
+		Purpose:   purpose,
+		TokenHash: auth.HashToken(token),
+		ExpiresAt: time.Now().UTC().Add(ttl),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return token, tx.Commit()
+}
+
+// appLink is a link to a page of the frontend carrying a mailed token.
+func (apiCfg *apiConfig) appLink(path, token string) string {
+	return apiCfg.AppURL + path + "?" + url.Values{"token": {token}}.Encode()
+}
+
+// sendPasswordReset mails user a link to choose a new password.
+func (apiCfg *apiConfig) sendPasswordReset(ctx context.Context, user database.User) error {
+	token, err := apiCfg.issueUserToken(ctx, user, userTokenPasswordReset, passwordResetTTL)
+	if err != nil {
+		return err
+	}
+
+	return apiCfg.Mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nSomeone asked to reset the password of your RSS Aggregator account. "+
+			"To choose a new password, open this link within the next hour:\n\n%s\n\n"+
+			"If it wasn't you, ignore this email and your password stays the same.\n",
+			user.Name, apiCfg.appLink("/reset-password", token)),
+	})
+}
+
+// sendEmailVerification mails user a link confirming their email address.
+func (apiCfg *apiConfig) sendEmailVerification(ctx context.Context, user database.User) error {
+	token, err := apiCfg.issueUserToken(ctx, user, userTokenEmailVerification, emailVerificationTTL)
+	if err != nil {
+		return err
+	}
+
+	return apiCfg.Mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Confirm your email address",
+		Body: fmt.Sprintf("Hi %s,\n\nPlease confirm this is your email address by opening this link within two days:\n\n%s\n",
+			user.Name, apiCfg.appLink("/verify-email", token)),
+	})
+}
+
+// emailChangedNotice tells the address user had before that the account now
+// uses another one, so a change the owner didn't make doesn't go unnoticed.
+func (apiCfg *apiConfig) emailChangedNotice(oldEmail string) func(context.Context, database.User) error {
+	return func(ctx context.Context, user database.User) error {
+		return apiCfg.Mailer.Send(ctx, mailer.Message{
+			To:      oldEmail,
+			Subject: "Your email address was changed",
+			Body: fmt.Sprintf("Hi %s,\n\nThe email address of your RSS Aggregator account was changed from %s to %s. "+
+				"Password reset and verification links sent to this address no longer work.\n\n"+
+				"If you didn't make this change, contact the site's administrators right away.\n",
+				user.Name, oldEmail, user.Email),
+		})
+	}
+}
+
+// sendInBackground runs send without holding up the response, logging
+// failures. Mail servers can be slow, and a reply that takes longer for
+// known addresses would give them away.
+func sendInBackground(what string, user database.User, send func(context.Context, database.User) error) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		err := send(ctx, user)
+		if err != nil {
+			log.Printf("Failed to send %s to user %v: %v", what, user.ID, err)
+		}
+	}()
+}
+
+// requireVerifiedEmail keeps users who haven't confirmed their email from
+// subscribing to feeds, when REQUIRE_EMAIL_VERIFICATION is on.
+func (apiCfg *apiConfig) requireVerifiedEmail(handler authHandler) authHandler {
+	return func(w http.ResponseWriter, r *http.Request, user database.User) {
+		if apiCfg.RequireEmailVerification && !user.EmailVerifiedAt.Valid {
+			respondWithError(w, http.StatusForbidden, "Verify your email address before subscribing to feeds")
+			return
+		}
+		handler(w, r, user)
+	}
+}
@@ -52,7 +52,7 @@ export default function FeedsPage() {
         const token = Cookies.get("authToken");
         try {
             if (followedFeedIds.has(feedId)) {
-                await axios.delete(`${apiUrl}/v1/feed_follows/${feedId}`, {
+                await axios.delete(`${apiUrl}/v1/feeds/${feedId}/follow`, {
                     headers: { Authorization: `Bearer ${token}` },
                 });
                 setFollowedFeedIds((prev) => {
@@ -42,7 +42,7 @@ export default function Dashboard() {
         const followedFeeds = feedsRes.data.filter((feed: Feed) =>
           followedFeedIds.includes(feed.id)
         );
-        const userPosts = postsRes.data.filter((post: Post) =>
+        const userPosts = postsRes.data.posts.filter((post: Post) =>
           followedFeedIds.includes(post.feed_id)
         );
 
@@ -22,13 +22,13 @@ export default function SavedPage() {
         const fetchData = async () => {
             const token = Cookies.get("authToken");
             const [postsRes, feedsRes] = await Promise.all([
-                axios.get(`${apiUrl}/v1/posts`, { headers: { Authorization: `Bearer ${token}` } }),
+                axios.get(`${apiUrl}/v1/posts?starred=true`, { headers: { Authorization: `Bearer ${token}` } }),
                 axios.get(`${apiUrl}/v1/feeds`),
             ]);
-            setPosts(postsRes?.data);
+            setPosts(postsRes?.data.posts);
             // filters feeds to only those that have posts
             const filteredFeeds = feedsRes?.data.filter((feed: Feed) =>
-                postsRes?.data.some((post: Post) => post.feed_id === feed.id)
+                postsRes?.data.posts.some((post: Post) => post.feed_id === feed.id)
             );
             setFeeds(filteredFeeds);
             // setFeeds(feedsRes?.data);
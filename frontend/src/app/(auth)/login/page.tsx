@@ -6,7 +6,7 @@ import { Input } from "@/components/ui/input";
 import { Card, CardContent, CardHeader, CardTitle } from "@/components/ui/card";
 import axios from "axios";
 import { toast } from "sonner";
-import Cookies from "js-cookie";
+import { storeTokens } from "@/lib/auth";
 import { useRouter } from "next/navigation";
 import Image from "next/image";
 import Link from "next/link";
@@ -27,13 +27,7 @@ export default function LoginPage() {
                 password,
             });
 
-            const { token } = response.data;
-
-            Cookies.set("authToken", token, {
-                expires: 7, // days
-                secure: process.env.NODE_ENV === "production",
-                sameSite: "Lax",
-            });
+            storeTokens(response.data);
 
             console.log("Login successful:", response?.data);
             toast.success("Login successful!");
@@ -17,6 +17,7 @@ import { Button } from "@/components/ui/button";
 import Cookies from "js-cookie";
 import axios from "axios";
 import { useTheme } from "next-themes";
+import { clearTokens } from "@/lib/auth";
 
 const navLinks = [
     { href: "/", label: "Home", icon: Home },
@@ -32,8 +33,15 @@ export default function Navbar() {
     const { theme, setTheme } = useTheme();
     const apiUrl = process.env.NEXT_PUBLIC_API_BASE_URL || "http://localhost:8080";
 
-    const handleLogout = () => {
-        Cookies.remove("authToken");
+    const handleLogout = async () => {
+        const token = Cookies.get("authToken");
+        if (token) {
+            // Ends the session on the server too, so its refresh token is useless.
+            await axios.post(`${apiUrl}/v1/auth/logout`, null, {
+                headers: { Authorization: `Bearer ${token}` },
+            }).catch((err) => console.error("Failed to log out", err));
+        }
+        clearTokens();
         Cookies.remove("userEmail");
         Cookies.remove("userName");
         window.location.href = "/login";
@@ -0,0 +1,78 @@
+import axios, { AxiosError, InternalAxiosRequestConfig } from "axios";
+import Cookies from "js-cookie";
+
+const apiUrl = process.env.NEXT_PUBLIC_API_BASE_URL || "http://localhost:8080";
+
+export type TokenPair = {
+    token: string;
+    expires_at: string;
+    refresh_token: string;
+    refresh_token_expires_at: string;
+};
+
+// Both cookies live as long as the session: the access token expires within
+// minutes, but an expired one is swapped for a fresh one on the first 401.
+export function storeTokens(tokens: TokenPair) {
+    const options: Cookies.CookieAttributes = {
+        expires: new Date(tokens.refresh_token_expires_at),
+        secure: process.env.NODE_ENV === "production",
+        sameSite: "Lax",
+    };
+    Cookies.set("authToken", tokens.token, options);
+    Cookies.set("refreshToken", tokens.refresh_token, options);
+}
+
+export function clearTokens() {
+    Cookies.remove("authToken");
+    Cookies.remove("refreshToken");
+}
+
+// Each refresh token works only once, so requests failing at the same time
+// share a single refresh instead of each spending the token.
+let refreshing: Promise<string> | null = null;
+
+function refreshAccessToken(): Promise<string> {
+    if (!refreshing) {
+        const refreshToken = Cookies.get("refreshToken");
+        refreshing = (refreshToken
+            ? axios.post<TokenPair>(`${apiUrl}/v1/auth/refresh`, { refresh_token: refreshToken })
+                .then((response) => {
+                    storeTokens(response.data);
+                    return response.data.token;
+                })
+            : Promise.reject(new Error("No refresh token"))
+        ).finally(() => {
+            refreshing = null;
+        });
+    }
+    return refreshing;
+}
+
+type RetryableConfig = InternalAxiosRequestConfig & { _retried?: boolean };
+
+if (typeof window !== "undefined") {
+    axios.interceptors.response.use(undefined, async (error: AxiosError) => {
+        const config = error.config as RetryableConfig | undefined;
+        if (
+            error.response?.status !== 401 ||
+            !config ||
+            config._retried ||
+            config.url?.startsWith(`${apiUrl}/v1/auth/`)
+        ) {
+            return Promise.reject(error);
+        }
+
+        let token: string;
+        try {
+            token = await refreshAccessToken();
+        } catch {
+            clearTokens();
+            window.location.href = "/login";
+            return Promise.reject(error);
+        }
+
+        config._retried = true;
+        config.headers.Authorization = `Bearer ${token}`;
+        return axios(config);
+    });
+}
@@ -4,7 +4,9 @@ const PUBLIC_PATHS = ["/login", "/register", "/_next", "/favicon.ico", "/api/pub
 
 export function middleware(req: NextRequest) {
     const { pathname } = req.nextUrl;
-    const token = req.cookies.get("authToken")?.value;
+    // An expired access token is renewed in the browser with the refresh
+    // token, so either one means the user is logged in.
+    const token = req.cookies.get("authToken")?.value || req.cookies.get("refreshToken")?.value;
 
     const isPublic = PUBLIC_PATHS.some((path) => pathname.startsWith(path));
     const isLoginPage = pathname === "/login";